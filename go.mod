@@ -1,3 +1,3 @@
 module github.com/jwhiteside11/squeue
 
-go 1.16
+go 1.23
@@ -17,7 +17,10 @@ permissionsand limitations under the License.
 */
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // Squeue - Performant double-ended queue data structure
@@ -79,10 +82,20 @@ import (
 // Squeue: main type
 // - Slice-based, circular queue that uses a cache to cut the time of necessary reallocations
 type Squeue struct {
-	head, tail                                 []interface{} // Head and tail slices, containing elements; add/delete operations of elements will occur on these slices
-	cache                                      []*Cached     // Cache of pointers to slices and the index of their first element
-	headF, headL, tailF, tailL, cacheF, cacheL int           // Circular pointers; F is the index to first element in queue, L is the index after the last element in queue (first available slot)
-	cacheSize                                  int           // Size of cache; element counts recorded as slices enter the cache (time amortized)
+	head, tail                                 []interface{}     // Head and tail slices, containing elements; add/delete operations of elements will occur on these slices
+	cache                                      []*Cached         // Cache of pointers to slices and the index of their first element
+	headF, headL, tailF, tailL, cacheF, cacheL int               // Circular pointers; F is the index to first element in queue, L is the index after the last element in queue (first available slot)
+	headN, tailN                               int               // Number of elements held by the head and tail slices; occupancy is not inferred from nil, so nil is a valid element
+	cacheSize                                  int               // Size of cache; element counts recorded as slices enter the cache (time amortized)
+	bound                                      int               // Maximum number of elements in a bounded queue; 0 if unbounded
+	ring                                       bool              // Bounded queue evicts from the opposite end when full, instead of refusing elements
+	pooled                                     bool              // Inner slices are taken from and released to a shared sync.Pool
+	maxInner                                   int               // Maximum length of inner slices allocated as the queue grows; 0 for defaultMaxInner
+	onAdd, onRemove                            func(interface{}) // Hooks called with each element added and removed; nil if unset
+	metrics                                    Metrics           // Counts of operations performed, since creation or ResetMetrics
+	growth                                     float64           // Factor by which new inner slices and the cache grow; 0 for doubling
+	autoCompact                                float64           // Ratio of Cap() to Size() above which a removal compacts the queue; 0 if off
+	extras                                     bool              // A bound, hook or auto compaction is set; adds and deletes check for them only if so
 }
 
 // Cached: underlying type for Squeue
@@ -91,6 +104,15 @@ type Cached struct {
 	idx int            // Index of the first element in the queue that is pointed at (queue is circular)
 }
 
+// ErrEmpty - returned when retrieving from a queue with no elements
+// Returned as is, so callers can match it with errors.Is; creating it once
+// also spares an allocation on every failed retrieval. Its message is kept
+// stable, for callers that still compare error text
+var ErrEmpty = errors.New("squeue: queue is empty")
+
+// ErrRange - wrapped by the error returned when a logical index falls outside the queue
+var ErrRange = errors.New("squeue: index out of range")
+
 /* Exports */
 
 // New - queue constructor, convinience method
@@ -102,90 +124,124 @@ func New(initial ...interface{}) Squeue {
 	copy(head, initial)
 	cache[0] = &Cached{&head, 0}
 
-	return Squeue{head, nil, cache, 0, n, 0, 0, 0, 1, 0}
+	return Squeue{head: head, cache: cache, headL: n, headN: n, cacheL: 1}
+}
+
+// FromSlice - queue constructor from an existing slice
+// Front of queue is s[0]; elements are copied, so s is not retained
+func FromSlice(s []interface{}) Squeue {
+	return New(s...)
+}
+
+// NewSized - queue constructor with room for exactly capacity elements in one inner slice
+// Accepts initial values to be enqueued, in the order listed; they count
+// toward capacity, which is raised to fit them (and to at least 1). Adding
+// allocates no inner slice until the queue holds more than capacity elements.
+// Panics if capacity < 0
+func NewSized(capacity int, initial ...interface{}) Squeue {
+	if capacity < 0 {
+		panic("squeue: invalid capacity")
+	}
+	n := len(initial)
+	head, cache := make([]interface{}, max(capacity, max(n, 1))), make([]*Cached, 6)
+
+	copy(head, initial)
+	cache[0] = &Cached{&head, 0}
+
+	return Squeue{head: head, cache: cache, headL: n % len(head), headN: n, cacheL: 1}
 }
 
 // Shift - add to front of queue
 // Add element to the head, increments head pointer
 func (sq *Squeue) Shift(elem interface{}) {
+	// Bounded queue must have room; see TryShift. A ring evicts from the back
+	if sq.extras && sq.IsFull() {
+		if !sq.ring {
+			panic(ErrFull)
+		}
+		sq.TryPop()
+	}
+	sq.metrics.Shifts++
 	// Check if head queue has room available
-	if !(sq.headL == sq.headF && sq.head[sq.headL] != nil) {
+	if sq.headN < len(sq.head) {
 		// Slots remain in head slice; set head pointer to next available, add elem
 		sq.headF -= 1
 		if sq.headF < 0 {
 			sq.headF += len(sq.head)
 		}
 		sq.head[sq.headF] = elem
-		return
-	}
-	// Head slice is full
-	switch {
-	case sq.tail == nil:
-		// If tail is nil, full head becomes tail, so head can become new slice
-		sq.tail = sq.head
-		sq.tailF, sq.tailL = sq.headF, sq.headL
-	default:
-		// If cache at capacity, reallocate to bigger slice
-		if sq.cacheF == sq.cacheL {
-			sq.grow()
-		}
-		// Head needs to be cached, set starting elem before caching
-		sq.cache[sq.cacheF].idx = sq.headF
-		sq.cacheSize += len(sq.head)
-	}
-	// Set new index
-	sq.cacheF -= 1
-	if sq.cacheF < 0 {
-		sq.cacheF += len(sq.cache)
-	}
-	// Check for slice in cache, set head
-	if sq.cache[sq.cacheF] != nil {
-		// Use empty slice from cache
-		sq.head = (*sq.cache[sq.cacheF].ptr)
-		sq.headF, sq.headL = 0, 0
+		sq.headN++
 	} else {
-		// Create new head slice, save pointer to cache
-		inner := make([]interface{}, min(2*max(len(sq.head), len(sq.tail)), 100000))
-		sq.cache[sq.cacheF] = &Cached{&inner, 0}
-		// Set head, pointers
-		sq.head = inner
-		sq.headF, sq.headL = 0, 1
+		// Head slice is full
+		switch {
+		case sq.tail == nil:
+			// If tail is nil, full head becomes tail, so head can become new slice
+			sq.tail = sq.head
+			sq.tailF, sq.tailL, sq.tailN = sq.headF, sq.headL, sq.headN
+		default:
+			// If cache at capacity, reallocate to bigger slice
+			if sq.cacheF == sq.cacheL {
+				sq.grow()
+			}
+			// Head needs to be cached, set starting elem before caching
+			sq.cache[sq.cacheF].idx = sq.headF
+			sq.cacheSize += len(sq.head)
+		}
+		// Set new index
+		sq.cacheF -= 1
+		if sq.cacheF < 0 {
+			sq.cacheF += len(sq.cache)
+		}
+		// Check for slice in cache, set head
+		if sq.cache[sq.cacheF] != nil {
+			// Use empty slice from cache
+			sq.head = (*sq.cache[sq.cacheF].ptr)
+		} else {
+			// Create new head slice, save pointer to cache
+			sq.cache[sq.cacheF] = sq.newInner(sq.innerCap(sq.grown(max(len(sq.head), len(sq.tail)))))
+			sq.head = (*sq.cache[sq.cacheF].ptr)
+		}
+		// Add elem to head, set pointers
+		sq.head[0] = elem
+		sq.headF, sq.headL, sq.headN = 0, 1, 1
+	}
+	if sq.onAdd != nil {
+		sq.onAdd(elem)
 	}
-	// Add elem to head
-	sq.head[sq.headF] = elem
 }
 
 // Push - add to back of queue (enqueue)
 // Adds element to tail, increments tail pointer
 func (sq *Squeue) Push(elem interface{}) {
-	switch {
-	case sq.tail == nil:
-		// Perform operation on head slice
-		if !(sq.headL == sq.headF && sq.head[sq.headL] != nil) {
-			// Slots remain in head slice; add elem, inc tail pointer
-			sq.head[sq.headL] = elem
-			sq.headL = (sq.headL + 1) % len(sq.head)
-			return
-		} else {
+	// Bounded queue must have room; see TryPush. A ring evicts from the front
+	if sq.extras && sq.IsFull() {
+		if !sq.ring {
+			panic(ErrFull)
+		}
+		sq.TryUnshift()
+	}
+	sq.metrics.Pushes++
+	if sq.tail == nil && sq.headN < len(sq.head) {
+		// Slots remain in head slice; add elem, inc tail pointer
+		sq.head[sq.headL] = elem
+		sq.headL = (sq.headL + 1) % len(sq.head)
+		sq.headN++
+	} else {
+		switch {
+		case sq.tail == nil:
 			// Head full, check for slice in cache
 			if sq.cache[sq.cacheL] != nil {
 				// Use empty slice from cache
 				sq.tail = (*sq.cache[sq.cacheL].ptr)
-				sq.tailF, sq.tailL = 0, 0
 			} else {
 				// New slice allocated
-				inner := make([]interface{}, min(2*len(sq.head), 100000))
-				sq.cache[sq.cacheL] = &Cached{&inner, 0}
-				// Set tail, pointers
-				sq.tail = inner
-				sq.tailF, sq.tailL = 0, 0
+				sq.cache[sq.cacheL] = sq.newInner(sq.innerCap(sq.grown(len(sq.head))))
+				sq.tail = (*sq.cache[sq.cacheL].ptr)
 			}
+			sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
 			// Inc outer tail pointer
 			sq.cacheL = (sq.cacheL + 1) % len(sq.cache)
-		}
-	default:
-		// Tail slice exists
-		if sq.tailL == sq.tailF && sq.tail[sq.tailL] != nil {
+		case sq.tailN == len(sq.tail):
 			// Tail at capacity
 			if sq.cacheL == sq.cacheF {
 				// Cache at capacity, grow outer slice
@@ -202,137 +258,124 @@ func (sq *Squeue) Push(elem interface{}) {
 			if sq.cache[sq.cacheL] != nil {
 				// Use empty slice from cache
 				sq.tail = (*sq.cache[sq.cacheL].ptr)
-				sq.tailF, sq.tailL = 0, 0
 			} else {
 				// Create new tail
-				inner := make([]interface{}, min(2*max(len(sq.head), len(sq.tail)), 100000))
-				sq.cache[sq.cacheL] = &Cached{&inner, 0}
-				// Set tail, pointers
-				sq.tail = inner
-				sq.tailF, sq.tailL = 0, 0
+				sq.cache[sq.cacheL] = sq.newInner(sq.innerCap(sq.grown(max(len(sq.head), len(sq.tail)))))
+				sq.tail = (*sq.cache[sq.cacheL].ptr)
 			}
+			sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
 			// Inc outer tail pointer
 			sq.cacheL = (sq.cacheL + 1) % len(sq.cache)
 		}
+		// Add elem to tail, inc tail pointer
+		sq.tail[sq.tailL] = elem
+		sq.tailL = (sq.tailL + 1) % len(sq.tail)
+		sq.tailN++
+	}
+	if sq.onAdd != nil {
+		sq.onAdd(elem)
 	}
-	// Add elem to tail, inc tail pointer
-	sq.tail[sq.tailL] = elem
-	sq.tailL = (sq.tailL + 1) % len(sq.tail)
 }
 
-// PeekFront - retrieve first element from queue without removing it
-// Checks for empty queue, if not returns first elem
-func (sq *Squeue) PeekFront() (interface{}, error) {
-	// Check for elem in head queue
-	elem := sq.head[sq.headF]
-	// Return elem if found
-	if elem != nil {
-		return elem, nil
+// PushAll - add elements to back of queue, in the order listed
+// Ex. PushAll(a, b, c) on [x] gives [x a b c]
+// Room for all of them is made first, as Grow, so an unbounded queue allocates
+// at most one slice.
+// Panics with ErrFull, before adding any, if a bounded queue lacks room for
+// all of them; a ring evicts as Push does
+func (sq *Squeue) PushAll(elems ...interface{}) {
+	sq.mustFit(len(elems))
+	// Make room for all at once; a ring evicts instead of growing past its bound
+	n := len(elems)
+	if sq.bound > 0 {
+		n = min(n, sq.bound-sq.Size())
 	}
-	// No elems in head; move to next slice in cache until only head left
-	d1, d2 := (sq.cacheF - 1), ((sq.cacheF + 1) % len(sq.cache))
-	if d1 < 0 {
-		d1 += len(sq.cache)
+	sq.Grow(n)
+	for _, elem := range elems {
+		sq.Push(elem)
 	}
-	// Check the last queue has been used
-	if d2 == sq.cacheL {
-		return nil, fmt.Errorf("no elements remaining in queue")
+}
+
+// ShiftAll - add elements to front of queue, keeping the order listed
+// Elements are shifted last to first, so the first argument ends up at the front
+// Ex. ShiftAll(a, b, c) on [x] gives [a b c x], not [c b a x]
+// Panics with ErrFull, before adding any, as PushAll
+func (sq *Squeue) ShiftAll(elems ...interface{}) {
+	sq.mustFit(len(elems))
+	for i := len(elems) - 1; i >= 0; i-- {
+		sq.Shift(elems[i])
 	}
-	// Void cached slice pointer if not in use
-	if sq.cacheF != sq.cacheL && d1 != sq.cacheL {
-		sq.cache[d1] = nil
+}
+
+// PeekFront - retrieve first element from queue without removing it
+// Checks for empty queue, if not returns first elem
+// Does not modify the queue; when the head is empty, the first elem is
+// looked up in the next slice holding elements instead
+func (sq *Squeue) PeekFront() (interface{}, error) {
+	// Return elem if head holds one
+	if sq.headN > 0 {
+		return sq.head[sq.headF], nil
 	}
-	// Inc outer head pointer
-	sq.cacheF = d2
-	// Get next slice
-	if ((sq.cacheF + 1) % len(sq.cache)) == sq.cacheL {
-		// Only one slice remains: the head should take the tail's place, and tail should be void
-		sq.head = sq.tail
-		sq.headF, sq.headL = sq.tailF, sq.tailL
-		sq.tail = nil
-	} else {
-		// Pointer taken from cache; dereference, and use as head
-		sq.head = (*sq.cache[sq.cacheF].ptr)
-		sq.cacheSize -= len(sq.head)
-		hF := sq.cache[sq.cacheF].idx
-		sq.headF, sq.headL = hF, hF
+	if sq.Empty() {
+		return nil, ErrEmpty
 	}
-	// Elem is nil; recurse until elem is found or cache is empty
-	return sq.PeekFront()
+	q, j := sq.locate(0)
+	return q[j], nil
 }
 
 // PeekBack - retrieve last element from queue without removing it
 // Checks for empty queue, if not returns last elem
+// Does not modify the queue; when the tail is empty, the last elem is
+// looked up in the previous slice holding elements instead
 func (sq *Squeue) PeekBack() (interface{}, error) {
-	var elem interface{}
-	d1 := sq.cacheF - 1
-	if d1 < 0 {
-		d1 += len(sq.cache)
+	if sq.Empty() {
+		return nil, ErrEmpty
 	}
-	if sq.tail == nil {
-		// Perform operation on head slice
-		if sq.headL == 0 {
-			elem = sq.head[len(sq.head)-1]
-		} else {
-			elem = sq.head[sq.headL-1]
-		}
-		// Return elem if found
-		if elem != nil {
-			return elem, nil
-		}
-		return nil, fmt.Errorf("no elements remaining in queue")
-	}
-	// Perform operation on tail slice
-	if sq.tailL == 0 {
-		elem = sq.tail[len(sq.tail)-1]
-	} else {
-		elem = sq.tail[sq.tailL-1]
-	}
-	// Return elem if found
-	if elem != nil {
-		return elem, nil
-	}
-	// No elems in tail; move to next slice in cache until only head left
-	d2, d3 := sq.cacheL-1, sq.cacheL-2
-	if d2 < 0 {
-		d2 += len(sq.cache)
-	}
-	if d3 < 0 {
-		d3 += len(sq.cache)
-	}
-	// Void cached slice pointer if not in use
-	if sq.cacheL != sq.cacheF && sq.cacheL != d1 {
-		sq.cache[sq.cacheL] = nil
+	q, j := sq.locate(sq.Size() - 1)
+	return q[j], nil
+}
+
+// PeekFrontOr - retrieve first element from queue without removing it, or def if empty
+// Does not modify the queue, as PeekFront
+func (sq *Squeue) PeekFrontOr(def interface{}) interface{} {
+	if elem, err := sq.PeekFront(); err == nil {
+		return elem
 	}
-	// Dec outer tail pointer
-	sq.cacheL = d2
-	if sq.cacheL == ((sq.cacheF + 1) % len(sq.cache)) {
-		// Last slice in cache, set tail to nil
-		sq.tail = nil
-	} else {
-		// Take pointer from cache, dereference, use as tail
-		sq.tail = (*sq.cache[d3].ptr)
-		tF := sq.cache[d3].idx
-		sq.tailF, sq.tailL = tF, tF
-		sq.cacheSize -= len(sq.tail)
+	return def
+}
+
+// PeekBackOr - retrieve last element from queue without removing it, or def if empty
+// Does not modify the queue, as PeekBack
+func (sq *Squeue) PeekBackOr(def interface{}) interface{} {
+	if elem, err := sq.PeekBack(); err == nil {
+		return elem
 	}
-	// Elem is nil; recurse until elem is found or cache is empty
-	return sq.PeekBack()
+	return def
+}
+
+// Front - retrieve first element from queue without removing it, and whether there was one
+// As a map lookup: ok is false, and elem nil, if the queue is empty. Does not
+// modify the queue or allocate
+func (sq *Squeue) Front() (elem interface{}, ok bool) {
+	elem, err := sq.PeekFront()
+	return elem, err == nil
+}
+
+// Back - retrieve last element from queue without removing it, and whether there was one
+// As Front, for the back of the queue
+func (sq *Squeue) Back() (elem interface{}, ok bool) {
+	elem, err := sq.PeekBack()
+	return elem, err == nil
 }
 
 // Unshift - remove element from front of queue (dequeue)
 // Retrieves the elem, and if successful deletes its value in the slice
 // Increments the head pointer to next elem in queue
 func (sq *Squeue) Unshift() (interface{}, error) {
-	// Get first elem in queue; if none, return error
-	elem, err := sq.PeekFront()
-	if err != nil {
-		return nil, err
+	elem, ok := sq.TryUnshift()
+	if !ok {
+		return nil, ErrEmpty
 	}
-	// Void element, move pointer
-	sq.head[sq.headF] = nil
-	sq.headF = (sq.headF + 1) % len(sq.head)
-
 	return elem, nil
 }
 
@@ -340,10 +383,42 @@ func (sq *Squeue) Unshift() (interface{}, error) {
 // Retrieves the elem, and if successful deletes its value in the slice
 // Decrements the tail pointer to next elem in queue
 func (sq *Squeue) Pop() (interface{}, error) {
-	// Get last elem in queue; if none, return error
-	elem, err := sq.PeekBack()
-	if err != nil {
-		return nil, err
+	elem, ok := sq.TryPop()
+	if !ok {
+		return nil, ErrEmpty
+	}
+	return elem, nil
+}
+
+// TryUnshift - remove element from front of queue, reporting success instead of an error
+// Returns false if the queue is empty; never allocates
+func (sq *Squeue) TryUnshift() (interface{}, bool) {
+	// Get first elem in queue, moving head to it; if none, report failure
+	elem, ok := sq.loadFront()
+	if !ok {
+		return nil, false
+	}
+	// Void element, move pointer
+	sq.head[sq.headF] = nil
+	sq.headF = (sq.headF + 1) % len(sq.head)
+	sq.headN--
+	sq.metrics.Unshifts++
+	if sq.extras {
+		sq.compactIfSparse()
+		if sq.onRemove != nil {
+			sq.onRemove(elem)
+		}
+	}
+	return elem, true
+}
+
+// TryPop - remove element from back of queue, reporting success instead of an error
+// Returns false if the queue is empty; never allocates
+func (sq *Squeue) TryPop() (interface{}, bool) {
+	// Get last elem in queue, moving tail to it; if none, report failure
+	elem, ok := sq.loadBack()
+	if !ok {
+		return nil, false
 	}
 	// Void element, move pointer
 	switch {
@@ -354,6 +429,7 @@ func (sq *Squeue) Pop() (interface{}, error) {
 			sq.headL += len(sq.head)
 		}
 		sq.head[sq.headL] = nil
+		sq.headN--
 	default:
 		// Perform operation on tail slice
 		sq.tailL -= 1
@@ -361,17 +437,357 @@ func (sq *Squeue) Pop() (interface{}, error) {
 			sq.tailL += len(sq.tail)
 		}
 		sq.tail[sq.tailL] = nil
+		sq.tailN--
+	}
+	sq.metrics.Pops++
+	if sq.extras {
+		sq.compactIfSparse()
+		if sq.onRemove != nil {
+			sq.onRemove(elem)
+		}
 	}
+	return elem, true
+}
 
-	return elem, nil
+// MustUnshift - remove element from front of queue, for callers that know it is not empty
+// Panics with ErrEmpty if the queue is empty
+func (sq *Squeue) MustUnshift() interface{} {
+	elem, ok := sq.TryUnshift()
+	if !ok {
+		panic(ErrEmpty)
+	}
+	return elem
+}
+
+// MustPop - remove element from back of queue, for callers that know it is not empty
+// Panics with ErrEmpty if the queue is empty
+func (sq *Squeue) MustPop() interface{} {
+	elem, ok := sq.TryPop()
+	if !ok {
+		panic(ErrEmpty)
+	}
+	return elem
+}
+
+// Enqueue - add to back of queue; same as Push
+func (sq *Squeue) Enqueue(elem interface{}) {
+	sq.Push(elem)
+}
+
+// Dequeue - remove element from front of queue; same as Unshift
+// Elements are dequeued in the order they were enqueued
+func (sq *Squeue) Dequeue() (interface{}, error) {
+	return sq.Unshift()
+}
+
+// PeekHead - retrieve element Dequeue would remove, without removing it; same as PeekFront
+func (sq *Squeue) PeekHead() (interface{}, error) {
+	return sq.PeekFront()
+}
+
+// At - retrieve element at logical index i (0 is the front) without removing it
+// Steps over whole inner slices using their recorded lengths rather than
+// over elements, so the cost depends on the number of slices, not on i
+func (sq *Squeue) At(i int) (interface{}, error) {
+	if i < 0 || i >= sq.Size() {
+		return nil, rangeError(i, sq.Size())
+	}
+	q, j := sq.locate(i)
+	return q[j], nil
+}
+
+// Swap - exchange elements at logical indices i and j
+// Errors, leaving the queue unchanged, if either index is out of range
+func (sq *Squeue) Swap(i, j int) error {
+	n := sq.Size()
+	if i < 0 || i >= n {
+		return rangeError(i, n)
+	}
+	if j < 0 || j >= n {
+		return rangeError(j, n)
+	}
+	qi, pi := sq.locate(i)
+	qj, pj := sq.locate(j)
+	qi[pi], qj[pj] = qj[pj], qi[pi]
+	return nil
+}
+
+// InsertAt - add element so that it ends up at logical index i (0 is the front)
+// The queue grows by one slot at the end nearer to i, and the elements in
+// between move one slot toward it, so at most Size()/2 elements are moved.
+// Returns ErrFull on a full bounded queue, including a ring: unlike Push and
+// Shift, it evicts nothing, as no end is the natural one to evict from
+func (sq *Squeue) InsertAt(i int, elem interface{}) error {
+	n := sq.Size()
+	if i < 0 || i > n {
+		return rangeError(i, n)
+	}
+	if sq.IsFull() {
+		return ErrFull
+	}
+	// Pull each element one slot toward the new end slot, then place elem at i
+	var pq []interface{}
+	var pj int
+	pull := func(k int, q []interface{}, j int) bool {
+		if pq != nil {
+			pq[pj] = q[j]
+		}
+		if k == i {
+			q[j] = elem
+			return false
+		}
+		pq, pj = q, j
+		return true
+	}
+	sq.quietly(func() {
+		if i < n/2 {
+			sq.Shift(nil)
+			sq.walkSlots(pull)
+		} else {
+			sq.Push(nil)
+			sq.walkBackSlots(pull)
+		}
+	})
+	if sq.onAdd != nil {
+		sq.onAdd(elem)
+	}
+	return nil
+}
+
+// InsertSorted - add element to a queue sorted according to cmp, keeping it sorted
+// cmp is as for Search. elem is placed after any elements equal to it, so
+// equal elements stay in insertion order. Errors only as InsertAt does
+func (sq *Squeue) InsertSorted(elem interface{}, cmp func(a, b interface{}) int) error {
+	// Search for the first element greater than elem
+	i, _ := sq.Search(elem, func(a, b interface{}) int {
+		if cmp(a, b) <= 0 {
+			return -1
+		}
+		return 1
+	})
+	return sq.InsertAt(i, elem)
+}
+
+// RemoveAt - remove and return element at logical index i (0 is the front)
+// Elements on the shorter side of i move one slot toward the gap, then the
+// vacated end slot is removed, so at most Size()/2 elements are moved
+func (sq *Squeue) RemoveAt(i int) (interface{}, error) {
+	n := sq.Size()
+	if i < 0 || i >= n {
+		return nil, rangeError(i, n)
+	}
+	// Carry each element one slot over, ending with the element at i in prev
+	var prev interface{}
+	carry := func(k int, q []interface{}, j int) bool {
+		q[j], prev = prev, q[j]
+		return k != i
+	}
+	sq.quietly(func() {
+		if i < n/2 {
+			// Move front elements back, freeing the front slot
+			sq.walkSlots(carry)
+			sq.TryUnshift()
+		} else {
+			// Move back elements forward, freeing the back slot
+			sq.walkBackSlots(carry)
+			sq.TryPop()
+		}
+	})
+	if sq.onRemove != nil {
+		sq.onRemove(prev)
+	}
+	return prev, nil
+}
+
+// RemoveFirst - remove the first element == elem, closing the gap
+// Returns false, leaving the queue unchanged, if no element matches. Ex.
+// cancelling one queued job; see RemoveFunc to remove every match
+func (sq *Squeue) RemoveFirst(elem interface{}) bool {
+	i := sq.IndexOf(elem)
+	if i < 0 {
+		return false
+	}
+	sq.RemoveAt(i)
+	return true
+}
+
+// RemoveLast - remove the last element == elem, closing the gap
+// Returns false, leaving the queue unchanged, if no element matches
+func (sq *Squeue) RemoveLast(elem interface{}) bool {
+	i := sq.LastIndexOf(elem)
+	if i < 0 {
+		return false
+	}
+	sq.RemoveAt(i)
+	return true
+}
+
+// RotateToFront - move the first element for which pred returns true to the front of queue
+// The elements before it each move back one place; returns false, leaving the
+// queue unchanged, if no element matches. Ex. promoting an entry in an LRU list.
+// A reordering: fires no hooks, and counts no add or delete in Metrics
+func (sq *Squeue) RotateToFront(pred func(interface{}) bool) bool {
+	i := -1
+	sq.walk(func(k int, v interface{}) bool {
+		if pred(v) {
+			i = k
+		}
+		return i < 0
+	})
+	if i < 0 {
+		return false
+	}
+	if i > 0 {
+		// The element only moves, so no hook fires and no add or delete is
+		// counted; a cache reallocation along the way still counts as a grow
+		m := sq.metrics
+		sq.quietly(func() {
+			elem, _ := sq.RemoveAt(i)
+			sq.Shift(elem)
+		})
+		m.Grows = sq.metrics.Grows
+		sq.metrics = m
+	}
+	return true
+}
+
+// UnshiftN - remove up to n elements from front of queue
+// Returns the removed elements front to back; if fewer than n remain, all
+// remaining elements are returned. Errors only if n is negative
+func (sq *Squeue) UnshiftN(n int) ([]interface{}, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative count %d", n)
+	}
+	n = min(n, sq.Size())
+	res := make([]interface{}, n)
+	for i := range res {
+		res[i], _ = sq.TryUnshift()
+	}
+	return res, nil
+}
+
+// PopN - remove up to n elements from back of queue
+// Returns the removed elements in the order they were popped, back first; if
+// fewer than n remain, all remaining elements are returned. Errors only if n is negative
+func (sq *Squeue) PopN(n int) ([]interface{}, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative count %d", n)
+	}
+	n = min(n, sq.Size())
+	res := make([]interface{}, n)
+	for i := range res {
+		res[i], _ = sq.TryPop()
+	}
+	return res, nil
+}
+
+// UnshiftWhile - remove elements from front of queue as long as pred returns true for them
+// Returns the removed elements front to back, empty if none; the first
+// element failing pred stays at the front
+func (sq *Squeue) UnshiftWhile(pred func(interface{}) bool) []interface{} {
+	res := make([]interface{}, 0)
+	for !sq.Empty() && pred(sq.PeekFrontOr(nil)) {
+		elem, _ := sq.TryUnshift()
+		res = append(res, elem)
+	}
+	return res
+}
+
+// PopWhile - remove elements from back of queue as long as pred returns true for them
+// Returns the removed elements in the order they were popped, back first,
+// empty if none; the first element failing pred stays at the back
+func (sq *Squeue) PopWhile(pred func(interface{}) bool) []interface{} {
+	res := make([]interface{}, 0)
+	for !sq.Empty() && pred(sq.PeekBackOr(nil)) {
+		elem, _ := sq.TryPop()
+		res = append(res, elem)
+	}
+	return res
+}
+
+// TrimFront - remove elements from front of queue as long as pred returns true for them
+// As UnshiftWhile, without collecting the removed elements
+func (sq *Squeue) TrimFront(pred func(interface{}) bool) {
+	for !sq.Empty() && pred(sq.PeekFrontOr(nil)) {
+		sq.TryUnshift()
+	}
+}
+
+// TrimBack - remove elements from back of queue as long as pred returns true for them
+// As PopWhile, without collecting the removed elements
+func (sq *Squeue) TrimBack(pred func(interface{}) bool) {
+	for !sq.Empty() && pred(sq.PeekBackOr(nil)) {
+		sq.TryPop()
+	}
+}
+
+// Trim - remove elements matching pred from both ends of queue, like strings.TrimFunc
+// Each end stops at its first element failing pred; if every element matches, the queue is emptied
+func (sq *Squeue) Trim(pred func(interface{}) bool) {
+	sq.TrimFront(pred)
+	sq.TrimBack(pred)
+}
+
+// KeepLast - discard elements from front of queue until at most n remain
+// Keeps the n elements nearest the back, in order; removed slots are voided so
+// their values can be collected. No-op if Size() <= n; n <= 0 empties the queue
+func (sq *Squeue) KeepLast(n int) {
+	for k := sq.Size() - max(n, 0); k > 0; k-- {
+		sq.TryUnshift()
+	}
+}
+
+// PeekAt - retrieve element at logical index i (0 is the front) without removing it
+// Never modifies the queue; out-of-range i returns an error matching ErrRange
+func (sq *Squeue) PeekAt(i int) (interface{}, error) {
+	return sq.At(i)
 }
 
 // Size - returns number of elements in queue
 // O(1) amortized time complexity
-// Cached slices record their length before caching, so only the size
-// of the head and the tail must be found, which are O(1)
+// Cached slices record their length before caching, and the head and
+// tail keep their own counts, so no slots need to be inspected
 func (sq *Squeue) Size() int {
-	return sq.headSize() + sq.cacheSize + sq.tailSize()
+	return sq.headN + sq.cacheSize + sq.tailN
+}
+
+// Cap - returns number of elements the queue can hold before allocating
+// Sums the lengths of the head, tail, and cached slices, and of the slice
+// waiting after the tail for the next push, e.g. one set aside by Grow;
+// always >= Size()
+func (sq *Squeue) Cap() int {
+	res, lenQ := len(sq.reserved()), len(sq.cache)
+	for k, c := 0, sq.cacheF; k < sq.liveSlots(); k, c = k+1, (c+1)%lenQ {
+		res += len(*sq.cache[c].ptr)
+	}
+	return res
+}
+
+// Grow - ensure room for n more elements to be pushed without allocating an inner slice
+// If the slice Push fills next lacks room, the missing room is allocated now
+// as one slice, queued up to be filled after it, regardless of the maximum
+// inner slice length; the slice is never shorter than the one Push would
+// allocate. No-op if the room is already there; panics if n < 0.
+// Shifts in between may use the room instead
+func (sq *Squeue) Grow(n int) {
+	if n < 0 {
+		panic("squeue: cannot Grow by a negative count")
+	}
+	spare := len(sq.head) - sq.headN
+	if sq.tail != nil {
+		spare = len(sq.tail) - sq.tailN
+	}
+	if spare >= n {
+		return
+	}
+	// The next slice Push takes is in the slot after the tail
+	if sq.cacheF == sq.cacheL {
+		sq.grow()
+	}
+	if next := sq.cache[sq.cacheL]; next == nil || len(*next.ptr) < n-spare {
+		sq.release(next)
+		sq.cache[sq.cacheL] = sq.newInner(max(n-spare, sq.innerCap(sq.grown(max(len(sq.head), len(sq.tail))))))
+	}
 }
 
 // Returns true if queue is empty
@@ -379,7 +795,111 @@ func (sq *Squeue) Empty() bool {
 	return sq.Size() == 0
 }
 
-// Each - returns underlying slice for iteration - convinience method
+// Merge - move all elements of other onto back of queue, front to back
+// other is left empty, as after Clear. Merging a queue into itself
+// behaves as MergeCopy. Panics with ErrFull, moving nothing, if a bounded
+// queue lacks room for all of other's elements; a ring evicts as Push does.
+// Elements are moved one at a time rather than by splicing other's inner
+// slices into the cache: cached slices must be full, which neither the
+// queue's tail nor other's head generally is, so the seams would need
+// copying anyway, and pooled slices would end up shared between queues
+func (sq *Squeue) Merge(other *Squeue) {
+	if other == sq {
+		sq.MergeCopy(other)
+		return
+	}
+	sq.mustFit(other.Size())
+	other.Drain(sq.Push)
+}
+
+// MergeCopy - add all elements of other onto back of queue, front to back
+// other is left unchanged. Panics with ErrFull, before adding any, as PushAll
+func (sq *Squeue) MergeCopy(other *Squeue) {
+	sq.mustFit(other.Size())
+	if other == sq {
+		sq.PushAll(sq.ToSlice()...)
+		return
+	}
+	other.walk(func(_ int, elem interface{}) bool {
+		sq.Push(elem)
+		return true
+	})
+}
+
+// Split - divide queue at logical index i
+// The queue keeps elements [0, i); the returned queue holds [i, Size()),
+// both in their original order. Errors, leaving the queue unchanged, if
+// i is outside [0, Size()]. The returned queue is configured as the queue
+// is: same bound, ring mode, pooling, options and hooks. Moving elements
+// between them fires neither queue's hooks, as the elements stay in use
+func (sq *Squeue) Split(i int) (Squeue, error) {
+	n := sq.Size()
+	if i < 0 || i > n {
+		return Squeue{}, rangeError(i, n)
+	}
+	// Move elements from the back onto the front of the new queue
+	res := sq.emptyLike()
+	sq.quietly(func() {
+		res.quietly(func() {
+			for k := i; k < n; k++ {
+				elem, _ := sq.TryPop()
+				res.Shift(elem)
+			}
+		})
+	})
+	return res, nil
+}
+
+// Drain - remove all elements from front to back, calling fn on each
+// Reads the inner slices directly instead of repeating Unshift, voiding each
+// slot as its element is handed to fn, then resets the queue as Clear does.
+// fn must not add to or remove from the queue
+func (sq *Squeue) Drain(fn func(interface{})) {
+	sq.walkSlots(func(_ int, q []interface{}, j int) bool {
+		elem := q[j]
+		q[j] = nil
+		fn(elem)
+		return true
+	})
+	sq.Clear()
+}
+
+// Clear - remove all elements from queue, retaining allocated memory for reuse
+// Voids every element reference so values can be garbage collected, then keeps
+// the largest inner slice as an empty head; the other slices are released.
+// Subsequent adds fill the retained slice before any new allocation
+func (sq *Squeue) Clear() {
+	keep := sq.cache[sq.cacheF]
+	clear(sq.head)
+	// Void slices between head and tail slots, and the tail itself
+	if sq.tail != nil {
+		lenQ := len(sq.cache)
+		for c := (sq.cacheF + 1) % lenQ; c != sq.cacheL; c = (c + 1) % lenQ {
+			q := *(sq.cache[c].ptr)
+			clear(q)
+			if len(q) > len(*keep.ptr) {
+				keep = sq.cache[c]
+			}
+		}
+	}
+	// Reset cache to hold only the retained slice; the others are all void
+	for c, e := range sq.cache {
+		if e != keep {
+			sq.release(e)
+		}
+		sq.cache[c] = nil
+	}
+	keep.idx = 0
+	sq.cache[0] = keep
+	sq.head, sq.tail = *keep.ptr, nil
+	sq.headF, sq.headL, sq.headN = 0, 0, 0
+	sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	sq.cacheF, sq.cacheL, sq.cacheSize = 0, 1, 0
+}
+
+// Each - returns elements in a slice for iteration - convinience method
+// The slice is newly allocated on every call and does not alias the queue's
+// inner slices; see ToSlice, which makes that guarantee explicit
 // Method takes values from memory in O(n) time; iteration is done most performantly
 // using delete operations (Unshift/Pop) until the queue is empty
 // Ex.
@@ -388,12 +908,235 @@ func (sq *Squeue) Empty() bool {
 //	   // do something with elem
 // }
 func (sq *Squeue) Each() []interface{} {
-	s := make([]interface{}, 0)
+	return sq.AppendTo(make([]interface{}, 0, sq.Size()))
+}
 
-	s = sq.appendHead(s)
-	s = sq.appendCache(s)
-	s = sq.appendTail(s)
+// AppendTo - appends elements to dst in queue order, returning the extended slice
+// Like append, allocates only when dst lacks capacity, so a buffer can be
+// reused across calls by passing it back in truncated to length 0
+// Ex.
+//
+//	buf = qq.AppendTo(buf[:0])
+func (sq *Squeue) AppendTo(dst []interface{}) []interface{} {
+	dst = sq.appendHead(dst)
+	dst = sq.appendCache(dst)
+	dst = sq.appendTail(dst)
 
+	return dst
+}
+
+// ForEach - calls fn on each element front to back, with its logical index, until fn returns false
+// Reads the inner slices in place and never modifies the queue, so it is safe
+// to stop early; the callback form of All2 for code not using range-over-func
+func (sq *Squeue) ForEach(fn func(i int, v interface{}) bool) {
+	sq.walk(fn)
+}
+
+// ForEachReverse - calls fn on each element back to front, with its logical index, until fn returns false
+// The first call gets index Size()-1; like ForEach, never modifies the queue
+func (sq *Squeue) ForEachReverse(fn func(i int, v interface{}) bool) {
+	sq.walkBack(fn)
+}
+
+// CopyTo - copies elements front to back into dst, returning the number copied
+// Copies min(len(dst), Size()) elements and never allocates; elements past
+// that count in dst are left untouched. Whole runs of each inner slice are
+// copied at once
+func (sq *Squeue) CopyTo(dst []interface{}) int {
+	n := 0
+	sq.walkRuns(func(run []interface{}) bool {
+		n += copy(dst[n:], run)
+		return n < len(dst)
+	})
+	return n
+}
+
+// ShrinkToFit - release unused capacity
+// Copies the elements, in order, into a single head slice sized as New would
+// size it for Size() elements; the tail and all cached slices are released
+func (sq *Squeue) ShrinkToFit() {
+	n := sq.Size()
+	head := make([]interface{}, 2*max(n, 10))
+	sq.walk(func(i int, elem interface{}) bool {
+		head[i] = elem
+		return true
+	})
+	sq.rebuild(head, n)
+}
+
+// Compact - consolidate the elements into as few inner slices as the maximum inner slice length allows
+// Copies the elements, in order, into slices of the maximum length, all full
+// but the last; the previous slices are released. A queue that fits in one
+// slice gets a single slice sized as by ShrinkToFit, within the maximum
+func (sq *Squeue) Compact() {
+	n, c := sq.Size(), sq.innerCap(2*max(sq.Size(), 10))
+	if n < c {
+		head := make([]interface{}, c)
+		sq.CopyTo(head)
+		sq.rebuild(head, n)
+		return
+	}
+	// c is the maximum inner slice length; fill k slices of that length
+	k := (n + c - 1) / c
+	inner := make([][]interface{}, k)
+	for j := range inner {
+		inner[j] = make([]interface{}, c)
+	}
+	sq.walk(func(i int, elem interface{}) bool {
+		inner[i/c][i%c] = elem
+		return true
+	})
+	sq.releaseAll()
+	cache := make([]*Cached, max(6, k+1))
+	for j := range inner {
+		cache[j] = &Cached{&inner[j], 0}
+	}
+	// Full head in slot 0, and if more than one slice, the tail in slot k-1
+	sq.head, sq.tail, sq.cache = inner[0], nil, cache
+	sq.headF, sq.headL, sq.headN = 0, 0, c
+	sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	sq.cacheF, sq.cacheL, sq.cacheSize = 0, k, 0
+	if k > 1 {
+		sq.tail = inner[k-1]
+		sq.tailN = n - (k-1)*c
+		sq.tailL = sq.tailN % c
+		sq.cacheSize = (k - 2) * c
+	}
+}
+
+// Reverse - reverse order of elements in place, so the front becomes the back
+// Elements are rewritten into the slots they already occupy; the slices and
+// pointers are left as they are, so the queue stays valid for further use
+func (sq *Squeue) Reverse() {
+	s, n := sq.ToSlice(), sq.Size()
+	sq.walkSlots(func(i int, q []interface{}, j int) bool {
+		q[j] = s[n-1-i]
+		return true
+	})
+}
+
+// Sort - sort elements in place, so that the front is the smallest according to less
+// Elements are sorted in a copy, then written back into the slots they
+// occupy; the slices and pointers are left as they are. Not stable
+func (sq *Squeue) Sort(less func(a, b interface{}) bool) {
+	s := sq.ToSlice()
+	sort.Slice(s, func(i, j int) bool {
+		return less(s[i], s[j])
+	})
+	sq.walkSlots(func(i int, q []interface{}, j int) bool {
+		q[j] = s[i]
+		return true
+	})
+}
+
+// Replace - replace every element == old with elem, in place, returning the number replaced
+// Size and order are unchanged; see the note on == in the query methods
+func (sq *Squeue) Replace(old, elem interface{}) int {
+	return sq.ReplaceFunc(func(v interface{}) bool {
+		return v == old
+	}, elem)
+}
+
+// ReplaceFunc - replace every element for which pred returns true with elem, in place, returning the number replaced
+func (sq *Squeue) ReplaceFunc(pred func(interface{}) bool, elem interface{}) int {
+	n := 0
+	sq.walkSlots(func(_ int, q []interface{}, j int) bool {
+		if pred(q[j]) {
+			q[j] = elem
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Dedup - remove consecutive duplicates, keeping the first element of each run
+// Adjacent elements a, b are duplicates if eq(a, b)
+func (sq *Squeue) Dedup(eq func(a, b interface{}) bool) {
+	s := sq.ToSlice()
+	kept, dropped := s[:0], []interface{}(nil)
+	for i, elem := range s {
+		if i == 0 || !eq(kept[len(kept)-1], elem) {
+			kept = append(kept, elem)
+		} else {
+			dropped = append(dropped, elem)
+		}
+	}
+	sq.retain(kept, dropped)
+}
+
+// DedupAll - remove all duplicates, keeping the first occurrence of each element
+// Elements a, b are duplicates if eq(a, b); each element is compared with
+// every kept element, so cost grows with the number of distinct elements
+func (sq *Squeue) DedupAll(eq func(a, b interface{}) bool) {
+	s := sq.ToSlice()
+	kept, dropped := s[:0], []interface{}(nil)
+	for _, elem := range s {
+		dup := false
+		for _, k := range kept {
+			if dup = eq(k, elem); dup {
+				break
+			}
+		}
+		if !dup {
+			kept = append(kept, elem)
+		} else {
+			dropped = append(dropped, elem)
+		}
+	}
+	sq.retain(kept, dropped)
+}
+
+// RemoveFunc - remove every element for which pred returns true, returning the number removed
+// The remaining elements keep their order. Done in a single pass, rather than
+// one RemoveAt per match, and the freed slots no longer refer to the elements
+func (sq *Squeue) RemoveFunc(pred func(interface{}) bool) int {
+	s := sq.ToSlice()
+	kept, dropped := s[:0], []interface{}(nil)
+	for _, elem := range s {
+		if pred(elem) {
+			dropped = append(dropped, elem)
+		} else {
+			kept = append(kept, elem)
+		}
+	}
+	if len(dropped) > 0 {
+		sq.retain(kept, dropped)
+	}
+	return len(dropped)
+}
+
+// Clone - returns an independent copy of the queue
+// Head, tail, cached slices, and the cache itself are freshly allocated, so
+// adding to or removing from either queue never affects the other. Elements
+// are copied shallowly; both queues refer to the same values
+func (sq *Squeue) Clone() Squeue {
+	cl := *sq
+	lenQ := len(sq.cache)
+	cl.cache = make([]*Cached, lenQ)
+	// Copy slices in use, from head slot to tail slot
+	for k, c := 0, sq.cacheF; k < sq.liveSlots(); k, c = k+1, (c+1)%lenQ {
+		inner := make([]interface{}, len(*sq.cache[c].ptr))
+		copy(inner, *sq.cache[c].ptr)
+		cl.cache[c] = &Cached{&inner, sq.cache[c].idx}
+	}
+	// Point head and tail at their copies
+	cl.head = *cl.cache[cl.cacheF].ptr
+	if sq.tail != nil {
+		cl.tail = *cl.cache[(cl.cacheL-1+lenQ)%lenQ].ptr
+	}
+	return cl
+}
+
+// ToSlice - returns a newly allocated slice of the elements, front to back
+// Never nil; an empty queue yields an empty slice. The slice belongs to the
+// caller, and modifying it does not affect the queue
+func (sq *Squeue) ToSlice() []interface{} {
+	s := make([]interface{}, 0, sq.Size())
+	sq.walk(func(_ int, elem interface{}) bool {
+		s = append(s, elem)
+		return true
+	})
 	return s
 }
 
@@ -403,91 +1146,329 @@ func (sq *Squeue) String() string {
 	return fmt.Sprint(sq.Each())
 }
 
+// Format - implements fmt.Formatter, so queues print sensibly under any verb
+// The verb, with its flags, width and precision, is applied to each element,
+// as fmt does for slices: %v gives [a b c], %5.2f pads and rounds each element.
+// %s formats String() as a whole, as it did before queues were Formatters.
+// %#v gives GoString(), Go syntax that rebuilds the queue.
+// A value receiver, unlike the other methods, so that printing a Squeue
+// value and not only a *Squeue goes through Format; fmt would otherwise dump
+// the struct fields. Only the struct header is copied, not the elements
+func (sq Squeue) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 's':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), sq.String())
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, sq.GoString())
+	default:
+		fmt.Fprintf(f, fmt.FormatString(f, verb), sq.ToSlice())
+	}
+}
+
+// GoString - Go source that rebuilds the queue; implements fmt.GoStringer
+// Ex. squeue.FromSlice([]interface {}{1, "x"}). Elements are written with %#v,
+// so the source compiles as long as theirs does. A value receiver, as for
+// Format, so that a Squeue value is a GoStringer too
+func (sq Squeue) GoString() string {
+	return fmt.Sprintf("squeue.FromSlice(%#v)", sq.ToSlice())
+}
+
+// Join - custom string representation: fn applied to each element, separated by sep
+// Elements appear front to back; an empty queue gives an empty string
+// Named after strings.Join; go vet reserves the name Format for fmt.Formatter
+// Ex. Join(", ", func(v interface{}) string { return fmt.Sprintf("%q", v) })
+func (sq *Squeue) Join(sep string, fn func(interface{}) string) string {
+	var b strings.Builder
+	sq.walk(func(i int, elem interface{}) bool {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(fn(elem))
+		return true
+	})
+	return b.String()
+}
+
 /* Internals */
 
-// Resize slice to double the number of elements in the queue
+// Resize slice to double the number of elements in the queue, or grow by the configured factor
 // For small n, place values at beginning of larger slice to prevent unnecessary allocations
 func (sq *Squeue) grow() {
+	sq.metrics.Grows++
 	n := cap(sq.cache)
 	if n < 6 {
 		sq.resize(8)
 	} else {
-		sq.resize(2 * n)
+		sq.resize(sq.grown(n))
+	}
+}
+
+// Returns n scaled by the growth factor, 2 unless configured; always at least n+1
+func (sq *Squeue) grown(n int) int {
+	if sq.growth == 0 {
+		return 2 * n
 	}
+	return max(int(float64(n)*sq.growth), n+1)
 }
 
 // Allocates bigger cache slices, copies elements from old onto new
 // Slice is in circular order, and are reset to 0th index
 // Reconfigures pointers to reflect shift
+// Only the slots in use, head to tail, are copied; m is raised to their
+// number if smaller, so no slice holding elements is ever dropped, and to
+// the 2 slots a head and a tail need
 func (sq *Squeue) resize(m int) {
+	n, lenQ := sq.liveSlots(), len(sq.cache)
+	m = max(m, max(n, 2))
 	// Allocate new cache slices
-	var j int = 0
 	qq := make([]*Cached, m)
-	// Copy existing values to beginning of new slice
-	switch {
-	case sq.cacheF == 0:
-		copy(qq, sq.cache)
-		j = len(sq.cache)
-	default:
-		lenq := len(sq.cache)
-		for i := sq.cacheF; i < lenq; i++ {
-			qq[j] = sq.cache[i]
-			j++
-		}
-		for i := 0; i < sq.cacheL; i++ {
-			qq[j] = sq.cache[i]
-			j++
-		}
+	// Copy slots in use to beginning of new slice, head first
+	for k := 0; k < n; k++ {
+		qq[k] = sq.cache[(sq.cacheF+k)%lenQ]
 	}
-	// Set pointers
+	// Set pointers; cacheL wraps to cacheF if the new cache is full
 	sq.cacheF = 0
-	sq.cacheL = j
+	sq.cacheL = n % m
 	// Set underlying slice as newly allocated slice
 	sq.cache = qq
 }
 
-func (sq *Squeue) headSize() int {
-	res := 0
-	if sq.head == nil {
-		return res
+// Moves the head forward past emptied slices until it holds the first elem, and returns it
+// Returns false if the queue is empty
+// Voids cached slices no longer in use; used by delete operations at the front
+func (sq *Squeue) loadFront() (interface{}, bool) {
+	// Return elem if head holds one
+	if sq.headN > 0 {
+		return sq.head[sq.headF], true
 	}
-	f, l := sq.headF, sq.headL
-	switch {
-	case f < l:
-		res = l - f
-	case f > l:
-		res = len(sq.head) - f + l
-	default:
-		if sq.head[f] != nil {
-			res = len(sq.head)
+	// No elems in head; move to next slice in cache until only head left
+	d1, d2 := (sq.cacheF - 1), ((sq.cacheF + 1) % len(sq.cache))
+	if d1 < 0 {
+		d1 += len(sq.cache)
+	}
+	// Check the last queue has been used
+	if d2 == sq.cacheL {
+		return nil, false
+	}
+	// Void cached slice pointer if not in use
+	if sq.cacheF != sq.cacheL && d1 != sq.cacheL {
+		sq.release(sq.cache[d1])
+		sq.cache[d1] = nil
+	}
+	// Inc outer head pointer
+	sq.cacheF = d2
+	// Get next slice
+	if ((sq.cacheF + 1) % len(sq.cache)) == sq.cacheL {
+		// Only one slice remains: the head should take the tail's place, and tail should be void
+		sq.head = sq.tail
+		sq.headF, sq.headL, sq.headN = sq.tailF, sq.tailL, sq.tailN
+		sq.tail = nil
+		sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	} else {
+		// Pointer taken from cache; dereference, and use as head
+		sq.head = (*sq.cache[sq.cacheF].ptr)
+		sq.cacheSize -= len(sq.head)
+		hF := sq.cache[sq.cacheF].idx
+		sq.headF, sq.headL, sq.headN = hF, hF, len(sq.head)
+	}
+	// Head is empty; recurse until elem is found or cache is empty
+	return sq.loadFront()
+}
+
+// Moves the tail backward past emptied slices until it, or the head if no tail remains, holds the last elem, and returns it
+// Returns false if the queue is empty
+// Voids cached slices no longer in use; used by delete operations at the back
+func (sq *Squeue) loadBack() (interface{}, bool) {
+	d1 := sq.cacheF - 1
+	if d1 < 0 {
+		d1 += len(sq.cache)
+	}
+	if sq.tail == nil {
+		// Perform operation on head slice
+		if sq.headN > 0 {
+			if sq.headL == 0 {
+				return sq.head[len(sq.head)-1], true
+			}
+			return sq.head[sq.headL-1], true
+		}
+		return nil, false
+	}
+	// Perform operation on tail slice
+	if sq.tailN > 0 {
+		if sq.tailL == 0 {
+			return sq.tail[len(sq.tail)-1], true
+		}
+		return sq.tail[sq.tailL-1], true
+	}
+	// No elems in tail; move to next slice in cache until only head left
+	d2, d3 := sq.cacheL-1, sq.cacheL-2
+	if d2 < 0 {
+		d2 += len(sq.cache)
+	}
+	if d3 < 0 {
+		d3 += len(sq.cache)
+	}
+	// Void cached slice pointer if not in use
+	if sq.cacheL != sq.cacheF && sq.cacheL != d1 {
+		sq.release(sq.cache[sq.cacheL])
+		sq.cache[sq.cacheL] = nil
+	}
+	// Dec outer tail pointer
+	sq.cacheL = d2
+	if sq.cacheL == ((sq.cacheF + 1) % len(sq.cache)) {
+		// Last slice in cache, set tail to nil
+		sq.tail = nil
+		sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	} else {
+		// Take pointer from cache, dereference, use as tail
+		sq.tail = (*sq.cache[d3].ptr)
+		tF := sq.cache[d3].idx
+		sq.tailF, sq.tailL, sq.tailN = tF, tF, len(sq.tail)
+		sq.cacheSize -= len(sq.tail)
+	}
+	// Tail is empty; recurse until elem is found or cache is empty
+	return sq.loadBack()
+}
+
+// Overwrites the first len(s) elements with s, in order, then removes the rest from the back
+// Requires len(s) <= Size(); slices already allocated are kept. The elements
+// no longer in the queue, dropped, are reported to the remove hook
+func (sq *Squeue) retain(s, dropped []interface{}) {
+	sq.walkSlots(func(i int, q []interface{}, j int) bool {
+		if i == len(s) {
+			return false
+		}
+		q[j] = s[i]
+		return true
+	})
+	sq.quietly(func() {
+		for k := sq.Size(); k > len(s); k-- {
+			sq.TryPop()
+		}
+	})
+	if sq.onRemove != nil {
+		for _, elem := range dropped {
+			sq.onRemove(elem)
 		}
 	}
+}
+
+// Records whether a bound, hook or auto compaction is set, for the adds and deletes to check
+// Called wherever one of them is set; the plain queue skips those checks
+func (sq *Squeue) setExtras() {
+	sq.extras = sq.bound > 0 || sq.onAdd != nil || sq.onRemove != nil || sq.autoCompact > 0
+}
+
+// Compacts the queue if auto compaction is on and capacity exceeds the ratio set
+// Sizes below 10 count as 10, as Compact keeps room for that many anyway;
+// otherwise a nearly empty queue would be compacted on every removal
+func (sq *Squeue) compactIfSparse() {
+	if sq.autoCompact > 0 && float64(sq.Cap()) > sq.autoCompact*float64(max(sq.Size(), 10)) {
+		sq.Compact()
+	}
+}
+
+// Replaces the elements of the queue with those of s, in order, keeping its bound
+// Fails if s does not fit within the bound
+func (sq *Squeue) load(s []interface{}) error {
+	n := len(s)
+	if sq.bound > 0 && n > sq.bound {
+		return ErrFull
+	}
+	head := make([]interface{}, 2*max(n, 10))
+	copy(head, s)
+	sq.rebuild(head, n)
+	return nil
+}
+
+// Replaces the internal structure with a single head slice whose first n slots hold the elements
+// Requires n < len(head), and head must be a new slice; the previous slices
+// are left to the garbage collector, or voided and released to the pool if
+// the queue is pooled
+func (sq *Squeue) rebuild(head []interface{}, n int) {
+	sq.releaseAll()
+	cache := make([]*Cached, 6)
+	cache[0] = &Cached{&head, 0}
+	sq.head, sq.tail, sq.cache = head, nil, cache
+	sq.headF, sq.headL, sq.headN = 0, n, n
+	sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	sq.cacheF, sq.cacheL, sq.cacheSize = 0, 1, 0
+}
+
+// Returns an empty queue configured as sq: same bound, ring mode, pooling, options and hooks
+// Its first inner slice is sized as New's; its metrics start from zero
+func (sq *Squeue) emptyLike() Squeue {
+	head, cache := make([]interface{}, sq.innerCap(20)), make([]*Cached, 6)
+	cache[0] = &Cached{&head, 0}
+	res := *sq
+	res.head, res.tail, res.cache = head, nil, cache
+	res.headF, res.headL, res.headN = 0, 0, 0
+	res.tailF, res.tailL, res.tailN = 0, 0, 0
+	res.cacheF, res.cacheL, res.cacheSize = 0, 1, 0
+	res.metrics = Metrics{}
 	return res
 }
 
-func (sq *Squeue) tailSize() int {
-	res := 0
-	if sq.tail == nil {
-		return res
+// Returns the length for a new inner slice: n, capped at the queue's maximum inner slice length
+func (sq *Squeue) innerCap(n int) int {
+	if sq.maxInner > 0 {
+		return min(n, sq.maxInner)
 	}
-	f, l := sq.tailF, sq.tailL
+	return min(n, defaultMaxInner)
+}
+
+// Returns the number of cache slots in use, from the head slot to the tail slot inclusive
+func (sq *Squeue) liveSlots() int {
 	switch {
-	case f < l:
-		res = l - f
-	case f > l:
-		res = len(sq.tail) - f + l
+	case sq.tail == nil:
+		return 1
+	case sq.cacheF == sq.cacheL:
+		return len(sq.cache)
 	default:
-		if sq.tail[f] != nil {
-			res = len(sq.tail)
+		return (sq.cacheL - sq.cacheF + len(sq.cache)) % len(sq.cache)
+	}
+}
+
+// Returns the slice in the slot after the tail slot, which Push fills once the tail is full
+// nil if the slot is empty or live
+func (sq *Squeue) reserved() []interface{} {
+	if sq.liveSlots() == len(sq.cache) || sq.cache[sq.cacheL] == nil {
+		return nil
+	}
+	return *sq.cache[sq.cacheL].ptr
+}
+
+// Resolve logical index i to the inner slice holding it and the element's slot in that slice
+// Caller must ensure 0 <= i < Size()
+func (sq *Squeue) locate(i int) ([]interface{}, int) {
+	// Index falls in head slice
+	if i < sq.headN {
+		return sq.head, (sq.headF + i) % len(sq.head)
+	}
+	i -= sq.headN
+	// Index falls in a cached slice; cached slices are full, so skip by length
+	if i < sq.cacheSize {
+		lenQ := len(sq.cache)
+		for c := (sq.cacheF + 1) % lenQ; ; c = (c + 1) % lenQ {
+			q := *(sq.cache[c].ptr)
+			if i < len(q) {
+				return q, (sq.cache[c].idx + i) % len(q)
+			}
+			i -= len(q)
 		}
 	}
-	return res
+	// Index falls in tail slice
+	i -= sq.cacheSize
+	return sq.tail, (sq.tailF + i) % len(sq.tail)
 }
 
 // Add values from head into slice in queue order, return slice
 func (sq *Squeue) appendHead(s []interface{}) []interface{} {
 	q, p, c := sq.head, sq.headF, sq.headL
 	lenq := len(q)
+	if sq.headN == 0 {
+		return s
+	}
 	if p < c {
 		for j := p; j < c; j++ {
 			s = append(s, q[j])
@@ -507,6 +1488,9 @@ func (sq *Squeue) appendHead(s []interface{}) []interface{} {
 func (sq *Squeue) appendTail(s []interface{}) []interface{} {
 	q, p, c := sq.tail, sq.tailF, sq.tailL
 	lenq := len(q)
+	if sq.tailN == 0 {
+		return s
+	}
 	if p < c {
 		for j := p; j < c; j++ {
 			s = append(s, q[j])
@@ -522,21 +1506,16 @@ func (sq *Squeue) appendTail(s []interface{}) []interface{} {
 	return s
 }
 
-// Get slice from reference in cache in queue order, then add their values in their queue order to a slice. Return the slice
+// Get slices from references in cache between head and tail, then add their values in their queue order to a slice. Return the slice
+// Interior slots run from cacheF+1 up to, not including, the tail slot at cacheL-1; both may wrap
 func (sq *Squeue) appendCache(s []interface{}) []interface{} {
-	switch {
-	case sq.cacheF < sq.cacheL:
-		for i := sq.cacheF + 1; i < sq.cacheL-1; i++ {
-			s = sq.appendCacheInner(s, i)
-		}
-	default:
-		lenQ := len(sq.cache)
-		for i := sq.cacheF + 1; i < lenQ; i++ {
-			s = sq.appendCacheInner(s, i)
-		}
-		for i := 0; i < sq.cacheL-1; i++ {
-			s = sq.appendCacheInner(s, i)
-		}
+	if sq.tail == nil {
+		return s
+	}
+	lenQ := len(sq.cache)
+	last := (sq.cacheL - 1 + lenQ) % lenQ
+	for i := (sq.cacheF + 1) % lenQ; i != last; i = (i + 1) % lenQ {
+		s = sq.appendCacheInner(s, i)
 	}
 	return s
 }
@@ -554,6 +1533,11 @@ func (sq *Squeue) appendCacheInner(s []interface{}, i int) []interface{} {
 	return s
 }
 
+// Returns the error reported for a logical index outside the queue; matches ErrRange with errors.Is
+func rangeError(i, n int) error {
+	return fmt.Errorf("%w: index %d, size %d", ErrRange, i, n)
+}
+
 // Returns the maximum of two integers; if equal, returns the first arguemnt
 func max(n, m int) int {
 	if m > n {
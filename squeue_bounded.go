@@ -0,0 +1,82 @@
+package squeue
+
+import (
+	"errors"
+)
+
+// Bounded queues
+//
+// A bounded queue holds at most a fixed number of elements. The bound limits
+// the logical element count only; inner slices are still allocated as for
+// an unbounded queue. TryPush and TryShift report a full queue with ErrFull,
+// while Push and Shift, which cannot return an error, panic with it.
+//
+// A ring is a bounded queue that keeps the most recent elements instead: when
+// full, Push evicts the front (oldest) element and Shift evicts the back one.
+// Evicted slots are voided like any removal, so the values can be collected.
+
+// ErrFull - returned when adding to a bounded queue that holds its maximum number of elements
+var ErrFull = errors.New("squeue: queue is full")
+
+// NewBounded - bounded queue constructor
+// Accepts the maximum number of elements, and initial values to be enqueued in the order listed
+// Panics if bound < 1 or more than bound initial values are given
+func NewBounded(bound int, initial ...interface{}) Squeue {
+	if bound < 1 || len(initial) > bound {
+		panic("squeue: invalid bound for bounded queue")
+	}
+	sq := New(initial...)
+	sq.bound = bound
+	sq.setExtras()
+	return sq
+}
+
+// NewRing - ring buffer constructor
+// Holds the last capacity elements pushed; ToSlice returns them oldest first
+// Panics if capacity < 1
+func NewRing(capacity int) Squeue {
+	sq := NewBounded(capacity)
+	sq.ring = true
+	return sq
+}
+
+// TryPush - add to back of queue, unless the queue is full
+// Returns ErrFull, leaving the queue unchanged, if a bound is reached; a ring does not evict here
+func (sq *Squeue) TryPush(elem interface{}) error {
+	if sq.IsFull() {
+		return ErrFull
+	}
+	sq.Push(elem)
+	return nil
+}
+
+// TryShift - add to front of queue, unless the queue is full
+// Returns ErrFull, leaving the queue unchanged, if a bound is reached; a ring does not evict here
+func (sq *Squeue) TryShift(elem interface{}) error {
+	if sq.IsFull() {
+		return ErrFull
+	}
+	sq.Shift(elem)
+	return nil
+}
+
+// IsFull - returns true if the queue is bounded and holds its maximum number of elements
+// Always false for an unbounded queue
+func (sq *Squeue) IsFull() bool {
+	return sq.bound > 0 && sq.Size() >= sq.bound
+}
+
+// Panics with ErrFull if a bounded queue, other than a ring, lacks room for n more elements
+// Lets bulk adds fail before adding anything, instead of partway through
+func (sq *Squeue) mustFit(n int) {
+	if sq.bound > 0 && !sq.ring && sq.Size()+n > sq.bound {
+		panic(ErrFull)
+	}
+}
+
+// Window - returns the elements a ring currently retains, oldest first, without removing them
+// Same as ToSlice, which works for any queue; named for reading a ring's
+// last-N window repeatedly, each call returning a fresh slice
+func (sq *Squeue) Window() []interface{} {
+	return sq.ToSlice()
+}
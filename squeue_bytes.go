@@ -0,0 +1,66 @@
+package squeue
+
+import (
+	"io"
+)
+
+// Byte queues
+//
+// ByteSqueue is a growable pipe buffer: writes append bytes to the back of an
+// SqueueOf[byte], and reads drain them from the front. A read from an empty
+// queue reports io.EOF only once CloseWrite has been called; before that it
+// returns 0, nil, so the reader can retry once more bytes are written.
+//
+// A ByteSqueue is not safe for concurrent use.
+
+/* Data Types */
+
+// ByteSqueue: byte queue implementing io.Reader and io.Writer
+type ByteSqueue struct {
+	sq   SqueueOf[byte] // Buffered bytes, oldest at the front
+	done bool           // Writer is done; reads from an empty queue return io.EOF
+}
+
+/* Exports */
+
+// NewByte - byte queue constructor
+// Accepts initial bytes to be buffered, in the order listed
+func NewByte(initial ...byte) *ByteSqueue {
+	return &ByteSqueue{sq: NewOf(initial...)}
+}
+
+// Write - append p to back of queue; implements io.Writer
+// Always writes all of p, unless CloseWrite has been called, which gives io.ErrClosedPipe
+func (b *ByteSqueue) Write(p []byte) (int, error) {
+	if b.done {
+		return 0, io.ErrClosedPipe
+	}
+	for _, c := range p {
+		b.sq.Push(c)
+	}
+	return len(p), nil
+}
+
+// Read - remove up to len(p) bytes from front of queue into p; implements io.Reader
+// Returns 0, nil on an empty queue, or 0, io.EOF once CloseWrite has been called
+func (b *ByteSqueue) Read(p []byte) (int, error) {
+	if b.sq.Empty() && b.done && len(p) > 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p), b.sq.Size())
+	for i := 0; i < n; i++ {
+		p[i], _ = b.sq.Unshift()
+	}
+	return n, nil
+}
+
+// CloseWrite - mark the writer as done
+// Bytes already written can still be read; after them, Read returns io.EOF
+func (b *ByteSqueue) CloseWrite() {
+	b.done = true
+}
+
+// Size - returns number of unread bytes
+func (b *ByteSqueue) Size() int {
+	return b.sq.Size()
+}
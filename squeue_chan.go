@@ -0,0 +1,25 @@
+package squeue
+
+// Channels
+//
+// Helpers for wiring queues into channel pipelines. They only send and
+// receive; opening and closing channels is left to the caller.
+
+// DrainTo - remove all elements from front to back, sending each on ch
+// Blocks while ch is full, and leaves the queue empty as Drain does. ch is not closed
+func (sq *Squeue) DrainTo(ch chan<- interface{}) {
+	sq.Drain(func(elem interface{}) {
+		ch <- elem
+	})
+}
+
+// FillFrom - push every value received from ch onto back of queue, until ch is closed
+// Values keep the order they were sent in. Blocks until ch is closed; like
+// Push, panics with ErrFull if a bounded queue fills, while a ring evicts.
+// The number of values is not known up front, so on a panic the values
+// received before it stay in the queue, and the rest stay in ch
+func (sq *Squeue) FillFrom(ch <-chan interface{}) {
+	for elem := range ch {
+		sq.Push(elem)
+	}
+}
@@ -0,0 +1,41 @@
+package squeue
+
+import (
+	"encoding/csv"
+	"fmt"
+)
+
+// CSV
+//
+// A queue maps to a single CSV record, one field per element, front to back.
+// Elements are written as text, so reading a record back gives a queue of
+// strings whatever the original element types were.
+
+// WriteCSVRow - writes the elements as one CSV record, front to back
+// Each element is formatted with fmt.Sprint, so strings are written as they
+// are. Like csv.Writer.Write, the record is buffered; call Flush on w after.
+// An empty queue, or one holding a single empty string, gives a blank line,
+// which csv.Reader skips
+func (sq *Squeue) WriteCSVRow(w *csv.Writer) error {
+	record := make([]string, 0, sq.Size())
+	sq.walk(func(_ int, elem interface{}) bool {
+		record = append(record, fmt.Sprint(elem))
+		return true
+	})
+	return w.Write(record)
+}
+
+// ReadCSVRow - queue constructor from the next CSV record read from r
+// Each field becomes a string element, in order; empty fields give empty
+// strings. Returns the error of r.Read, such as io.EOF after the last record
+func ReadCSVRow(r *csv.Reader) (Squeue, error) {
+	record, err := r.Read()
+	if err != nil {
+		return New(), err
+	}
+	sq := New()
+	for _, field := range record {
+		sq.Push(field)
+	}
+	return sq, nil
+}
@@ -0,0 +1,53 @@
+package squeue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Debugging
+//
+// A dump of the internal structure, for diagnosing edge cases in the pointer
+// arithmetic. Inner slices are printed whole, including unused slots, so the
+// output is only practical for small queues.
+
+// DebugString - returns a multi-line dump of the internal structure
+// Shows the cache pointers and element count, the head and tail slices with
+// their pointers, and each cache entry's idx and slice length. Read-only
+// Ex.
+//
+//	size=3 cacheF=0 cacheL=1 cacheSize=0
+//	head: F=0 L=3 N=3 [1 2 3 <nil> <nil>]
+//	tail: nil
+//	cache[0]: idx=0 len=5 (head)
+//	cache[1]: nil
+func (sq *Squeue) DebugString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "size=%d cacheF=%d cacheL=%d cacheSize=%d\n", sq.Size(), sq.cacheF, sq.cacheL, sq.cacheSize)
+	fmt.Fprintf(&b, "head: F=%d L=%d N=%d %v\n", sq.headF, sq.headL, sq.headN, sq.head)
+	if sq.tail == nil {
+		b.WriteString("tail: nil\n")
+	} else {
+		fmt.Fprintf(&b, "tail: F=%d L=%d N=%d %v\n", sq.tailF, sq.tailL, sq.tailN, sq.tail)
+	}
+	lenQ := len(sq.cache)
+	tailSlot := -1
+	if sq.tail != nil {
+		tailSlot = (sq.cacheL - 1 + lenQ) % lenQ
+	}
+	for c, e := range sq.cache {
+		if e == nil {
+			fmt.Fprintf(&b, "cache[%d]: nil\n", c)
+			continue
+		}
+		fmt.Fprintf(&b, "cache[%d]: idx=%d len=%d", c, e.idx, len(*e.ptr))
+		switch c {
+		case sq.cacheF:
+			b.WriteString(" (head)")
+		case tailSlot:
+			b.WriteString(" (tail)")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
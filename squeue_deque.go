@@ -0,0 +1,22 @@
+package squeue
+
+// Deque - the core double-ended queue operations
+//
+// Lets callers program against the abstraction rather than a concrete queue.
+// *Squeue satisfies it, including bounded, ring and pooled queues, as does
+// *SyncSqueue for concurrent use.
+type Deque interface {
+	Push(elem interface{})           // Add to back of queue
+	Shift(elem interface{})          // Add to front of queue
+	Pop() (interface{}, error)       // Remove element from back of queue
+	Unshift() (interface{}, error)   // Remove element from front of queue
+	PeekFront() (interface{}, error) // Retrieve first element without removing it
+	PeekBack() (interface{}, error)  // Retrieve last element without removing it
+	Size() int                       // Number of elements in queue
+	Empty() bool                     // True if queue holds no elements
+}
+
+var (
+	_ Deque = (*Squeue)(nil)
+	_ Deque = (*SyncSqueue)(nil)
+)
@@ -0,0 +1,56 @@
+package squeue
+
+import (
+	"fmt"
+)
+
+// Diffs
+//
+// Element-by-element comparison of two queues, for test failure messages
+// that point at the differing positions rather than printing both queues.
+// Elements are compared with ==, which panics for two elements of the same
+// non-comparable type, as in Equal.
+
+/* Data Types */
+
+// DiffEntry: a logical index at which two queues differ
+type DiffEntry struct {
+	Index    int         // Logical index in both queues (0 is the front)
+	A, B     interface{} // Elements of a and b at Index; nil if InA or InB is false
+	InA, InB bool        // Whether a and b have an element at Index; both true unless the lengths differ
+}
+
+/* Exports */
+
+// Diff - returns the logical indices at which a and b differ, in order
+// Covers elements that are unequal, then those past the end of the shorter
+// queue. Empty if the queues are Equal
+func Diff(a, b *Squeue) []DiffEntry {
+	sa, sb := a.ToSlice(), b.ToSlice()
+	var res []DiffEntry
+	for i := 0; i < max(len(sa), len(sb)); i++ {
+		e := DiffEntry{Index: i, InA: i < len(sa), InB: i < len(sb)}
+		if e.InA {
+			e.A = sa[i]
+		}
+		if e.InB {
+			e.B = sb[i]
+		}
+		if !e.InA || !e.InB || e.A != e.B {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// String - describes the difference, e.g. "[2]: 5 != 6" or "[4]: 7 only in a"
+func (e DiffEntry) String() string {
+	switch {
+	case !e.InB:
+		return fmt.Sprintf("[%d]: %v only in a", e.Index, e.A)
+	case !e.InA:
+		return fmt.Sprintf("[%d]: %v only in b", e.Index, e.B)
+	default:
+		return fmt.Sprintf("[%d]: %v != %v", e.Index, e.A, e.B)
+	}
+}
@@ -0,0 +1,206 @@
+package squeue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Encoding support
+//
+// Only the logical sequence of elements is encoded, front to back; the
+// circular pointers and slice layout are rebuilt on decode.
+//
+// WriteTo and ReadFrom stream a gob encoding instead of building it in
+// memory: the element count, then one gob value per element. Each element
+// carries its type name, so the stream is larger than GobEncode's, in
+// exchange for never holding more than one element's encoding.
+
+// MarshalJSON - encodes the queue as a JSON array, front to back
+func (sq Squeue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sq.ToSlice())
+}
+
+// UnmarshalJSON - rebuilds the queue from a JSON array
+// Any existing contents of the receiver are replaced; a bound set on the
+// receiver is kept, and ErrFull is returned if the array exceeds it. As
+// encoding/json does for other values, null leaves the receiver unchanged
+func (sq *Squeue) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var s []interface{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return sq.load(s)
+}
+
+// GobEncode - encodes the elements with encoding/gob, front to back
+// As with any interface{} value sent through gob, concrete element types
+// must be registered with gob.Register
+func (sq Squeue) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sq.ToSlice()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode - rebuilds the queue from data produced by GobEncode
+// Any existing contents of the receiver are replaced; a bound set on the
+// receiver is kept, and ErrFull is returned if the data exceeds it
+func (sq *Squeue) GobDecode(data []byte) error {
+	var s []interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	return sq.load(s)
+}
+
+// MarshalBinary - encodes the queue as a length-prefixed gob encoding of the elements
+// The uvarint length of the GobEncode data comes first, so the encoding can
+// be embedded in other binary streams and its end found without decoding it
+func (sq Squeue) MarshalBinary() ([]byte, error) {
+	data, err := sq.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	return append(binary.AppendUvarint(nil, uint64(len(data))), data...), nil
+}
+
+// UnmarshalBinary - rebuilds the queue from data produced by MarshalBinary
+// Any existing contents of the receiver are replaced; a bound set on the
+// receiver is kept, and ErrFull is returned if the data exceeds it
+func (sq *Squeue) UnmarshalBinary(data []byte) error {
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n != uint64(len(data)-k) {
+		return errors.New("squeue: binary data length does not match its prefix")
+	}
+	return sq.GobDecode(data[k:])
+}
+
+// MarshalText - encodes a queue of strings as its elements separated by newlines, front to back
+// Only meaningful for string elements: errors if an element is not a string,
+// or contains a newline and so would not decode as one element. Also errors
+// for a queue holding just the empty string, whose text is empty and would
+// decode as an empty queue
+func (sq Squeue) MarshalText() ([]byte, error) {
+	var err error
+	text := sq.Join("\n", func(elem interface{}) string {
+		s, ok := elem.(string)
+		switch {
+		case err != nil:
+		case !ok:
+			err = fmt.Errorf("squeue: cannot marshal %T element as text", elem)
+		case strings.Contains(s, "\n"):
+			err = fmt.Errorf("squeue: cannot marshal element %q containing a newline as text", s)
+		}
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	if text == "" && sq.Size() == 1 {
+		return nil, errors.New("squeue: cannot marshal a single empty string as text")
+	}
+	return []byte(text), nil
+}
+
+// UnmarshalText - rebuilds the queue from newline-separated text, one string element per line
+// Empty text gives an empty queue. Any existing contents of the receiver are
+// replaced; a bound set on the receiver is kept, and ErrFull is returned if
+// the text exceeds it
+func (sq *Squeue) UnmarshalText(text []byte) error {
+	var s []interface{}
+	if len(text) > 0 {
+		for _, line := range strings.Split(string(text), "\n") {
+			s = append(s, line)
+		}
+	}
+	return sq.load(s)
+}
+
+// WriteTo - streams the element count and then each element, gob-encoded, to w; implements io.WriterTo
+// Returns the number of bytes written. Element types must be registered with
+// gob.Register, as for GobEncode
+func (sq *Squeue) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	enc := gob.NewEncoder(cw)
+	if err := enc.Encode(sq.Size()); err != nil {
+		return cw.n, err
+	}
+	var err error
+	sq.walk(func(_ int, elem interface{}) bool {
+		err = enc.Encode(&elem)
+		return err == nil
+	})
+	return cw.n, err
+}
+
+// ReadFrom - rebuilds the queue from a stream written by WriteTo; implements io.ReaderFrom
+// Returns the number of bytes read, and reads no further than the end of the
+// stream, so r may hold other data after it. Any existing contents of the
+// receiver are replaced; a bound set on the receiver is kept, and ErrFull is
+// returned if the stream exceeds it
+func (sq *Squeue) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	dec := gob.NewDecoder(cr)
+	var n int
+	if err := dec.Decode(&n); err != nil {
+		return cr.n, err
+	}
+	if n < 0 {
+		return cr.n, errors.New("squeue: negative element count in stream")
+	}
+	s := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		var elem interface{}
+		if err := dec.Decode(&elem); err != nil {
+			return cr.n, err
+		}
+		s = append(s, elem)
+	}
+	return cr.n, sq.load(s)
+}
+
+/* Internals */
+
+// Writer counting the bytes written through it
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Reader counting the bytes read through it
+// Implements io.ByteReader, so that gob reads exactly the bytes of its
+// messages instead of buffering ahead
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countReader) ReadByte() (byte, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(cr, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
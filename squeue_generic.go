@@ -0,0 +1,455 @@
+package squeue
+
+import (
+	"fmt"
+)
+
+// SqueueOf - type-safe counterpart to Squeue, parameterized over the element type
+//
+// SqueueOf mirrors the Squeue API and keeps the same circular cache design,
+// so the performance characteristics carry over. Elements are stored in []T
+// slices rather than []interface{}, which avoids boxing on add and type
+// assertions on retrieval.
+//
+// Squeue uses nil as the "slot empty" sentinel; the zero value of an arbitrary
+// T cannot serve that purpose, so the head and tail slices record how many
+// elements they hold (headN, tailN) instead. Cached inner slices are always
+// full, so their counts are implied by their length.
+
+/* Data Types */
+
+// SqueueOf: generic type
+// - Slice-based, circular queue that uses a cache to cut the time of necessary reallocations
+type SqueueOf[T any] struct {
+	head, tail                                 []T            // Head and tail slices, containing elements; add/delete operations of elements will occur on these slices
+	cache                                      []*CachedOf[T] // Cache of pointers to slices and the index of their first element
+	headF, headL, tailF, tailL, cacheF, cacheL int            // Circular pointers; F is the index to first element in queue, L is the index after the last element in queue (first available slot)
+	headN, tailN                               int            // Number of elements held by the head and tail slices
+	cacheSize                                  int            // Size of cache; element counts recorded as slices enter the cache (time amortized)
+}
+
+// CachedOf: underlying type for SqueueOf
+type CachedOf[T any] struct {
+	ptr *[]T // Pointer to a queue that exists in the SqueueOf data structure
+	idx int  // Index of the first element in the queue that is pointed at (queue is circular)
+}
+
+/* Exports */
+
+// NewOf - generic queue constructor
+// Accepts initial values to be enqueued, in the order listed
+func NewOf[T any](initial ...T) SqueueOf[T] {
+	n := len(initial)
+	head, cache := make([]T, 2*max(n, 10)), make([]*CachedOf[T], 6)
+
+	copy(head, initial)
+	cache[0] = &CachedOf[T]{&head, 0}
+
+	return SqueueOf[T]{head: head, cache: cache, headL: n, headN: n, cacheL: 1}
+}
+
+// Shift - add to front of queue
+// Add element to the head, increments head pointer
+func (sq *SqueueOf[T]) Shift(elem T) {
+	// Check if head queue has room available
+	if sq.headN < len(sq.head) {
+		// Slots remain in head slice; set head pointer to next available, add elem
+		sq.headF -= 1
+		if sq.headF < 0 {
+			sq.headF += len(sq.head)
+		}
+		sq.head[sq.headF] = elem
+		sq.headN++
+		return
+	}
+	// Head slice is full
+	switch {
+	case sq.tail == nil:
+		// If tail is nil, full head becomes tail, so head can become new slice
+		sq.tail = sq.head
+		sq.tailF, sq.tailL, sq.tailN = sq.headF, sq.headL, sq.headN
+	default:
+		// If cache at capacity, reallocate to bigger slice
+		if sq.cacheF == sq.cacheL {
+			sq.grow()
+		}
+		// Head needs to be cached, set starting elem before caching
+		sq.cache[sq.cacheF].idx = sq.headF
+		sq.cacheSize += len(sq.head)
+	}
+	// Set new index
+	sq.cacheF -= 1
+	if sq.cacheF < 0 {
+		sq.cacheF += len(sq.cache)
+	}
+	// Check for slice in cache, set head
+	if sq.cache[sq.cacheF] != nil {
+		// Use empty slice from cache
+		sq.head = (*sq.cache[sq.cacheF].ptr)
+	} else {
+		// Create new head slice, save pointer to cache
+		inner := make([]T, min(2*max(len(sq.head), len(sq.tail)), defaultMaxInner))
+		sq.cache[sq.cacheF] = &CachedOf[T]{&inner, 0}
+		sq.head = inner
+	}
+	// Add elem to head, set pointers
+	sq.head[0] = elem
+	sq.headF, sq.headL, sq.headN = 0, 1, 1
+}
+
+// Push - add to back of queue (enqueue)
+// Adds element to tail, increments tail pointer
+func (sq *SqueueOf[T]) Push(elem T) {
+	switch {
+	case sq.tail == nil:
+		// Perform operation on head slice
+		if sq.headN < len(sq.head) {
+			// Slots remain in head slice; add elem, inc tail pointer
+			sq.head[sq.headL] = elem
+			sq.headL = (sq.headL + 1) % len(sq.head)
+			sq.headN++
+			return
+		}
+		// Head full, check for slice in cache
+		if sq.cache[sq.cacheL] != nil {
+			// Use empty slice from cache
+			sq.tail = (*sq.cache[sq.cacheL].ptr)
+		} else {
+			// New slice allocated
+			inner := make([]T, min(2*len(sq.head), defaultMaxInner))
+			sq.cache[sq.cacheL] = &CachedOf[T]{&inner, 0}
+			sq.tail = inner
+		}
+		sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+		// Inc outer tail pointer
+		sq.cacheL = (sq.cacheL + 1) % len(sq.cache)
+	default:
+		// Tail slice exists
+		if sq.tailN == len(sq.tail) {
+			// Tail at capacity
+			if sq.cacheL == sq.cacheF {
+				// Cache at capacity, grow outer slice
+				sq.grow()
+			}
+			d1 := sq.cacheL - 1
+			if d1 < 0 {
+				d1 += len(sq.cache)
+			}
+			// Add tail pointer to cache, record size
+			sq.cache[d1].idx = sq.tailF
+			sq.cacheSize += len(sq.tail)
+			// Check for slice in cache, set tail
+			if sq.cache[sq.cacheL] != nil {
+				// Use empty slice from cache
+				sq.tail = (*sq.cache[sq.cacheL].ptr)
+			} else {
+				// Create new tail
+				inner := make([]T, min(2*max(len(sq.head), len(sq.tail)), defaultMaxInner))
+				sq.cache[sq.cacheL] = &CachedOf[T]{&inner, 0}
+				sq.tail = inner
+			}
+			sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+			// Inc outer tail pointer
+			sq.cacheL = (sq.cacheL + 1) % len(sq.cache)
+		}
+	}
+	// Add elem to tail, inc tail pointer
+	sq.tail[sq.tailL] = elem
+	sq.tailL = (sq.tailL + 1) % len(sq.tail)
+	sq.tailN++
+}
+
+// PeekFront - retrieve first element from queue without removing it
+// Checks for empty queue, if not returns first elem
+// Does not modify the queue; when the head is empty, the first elem is
+// looked up in the next slice holding elements instead
+func (sq *SqueueOf[T]) PeekFront() (T, error) {
+	// Return elem if head holds one
+	if sq.headN > 0 {
+		return sq.head[sq.headF], nil
+	}
+	if sq.Empty() {
+		var zero T
+		return zero, ErrEmpty
+	}
+	q, j := sq.locate(0)
+	return q[j], nil
+}
+
+// PeekBack - retrieve last element from queue without removing it
+// Checks for empty queue, if not returns last elem
+// Does not modify the queue; when the tail is empty, the last elem is
+// looked up in the previous slice holding elements instead
+func (sq *SqueueOf[T]) PeekBack() (T, error) {
+	if sq.Empty() {
+		var zero T
+		return zero, ErrEmpty
+	}
+	q, j := sq.locate(sq.Size() - 1)
+	return q[j], nil
+}
+
+// Unshift - remove element from front of queue (dequeue)
+// Retrieves the elem, and if successful deletes its value in the slice
+// Increments the head pointer to next elem in queue
+func (sq *SqueueOf[T]) Unshift() (T, error) {
+	// Get first elem in queue, moving head to it; if none, return error
+	elem, ok := sq.loadFront()
+	if !ok {
+		return elem, ErrEmpty
+	}
+	// Void element, move pointer
+	var zero T
+	sq.head[sq.headF] = zero
+	sq.headF = (sq.headF + 1) % len(sq.head)
+	sq.headN--
+
+	return elem, nil
+}
+
+// Pop - remove element from back of queue
+// Retrieves the elem, and if successful deletes its value in the slice
+// Decrements the tail pointer to next elem in queue
+func (sq *SqueueOf[T]) Pop() (T, error) {
+	// Get last elem in queue, moving tail to it; if none, return error
+	elem, ok := sq.loadBack()
+	if !ok {
+		return elem, ErrEmpty
+	}
+	// Void element, move pointer
+	var zero T
+	switch {
+	case sq.tail == nil:
+		// Perform operation on head slice
+		sq.headL -= 1
+		if sq.headL < 0 {
+			sq.headL += len(sq.head)
+		}
+		sq.head[sq.headL] = zero
+		sq.headN--
+	default:
+		// Perform operation on tail slice
+		sq.tailL -= 1
+		if sq.tailL < 0 {
+			sq.tailL += len(sq.tail)
+		}
+		sq.tail[sq.tailL] = zero
+		sq.tailN--
+	}
+
+	return elem, nil
+}
+
+// Size - returns number of elements in queue
+// O(1) time complexity; head and tail keep their own counts, cached
+// slices record their length before caching
+func (sq *SqueueOf[T]) Size() int {
+	return sq.headN + sq.cacheSize + sq.tailN
+}
+
+// Returns true if queue is empty
+func (sq *SqueueOf[T]) Empty() bool {
+	return sq.Size() == 0
+}
+
+// Each - returns a new slice containing the elements in queue order - convinience method
+// Method takes values from memory in O(n) time
+func (sq *SqueueOf[T]) Each() []T {
+	s := make([]T, 0, sq.Size())
+
+	s = appendInnerOf(s, sq.head, sq.headF, sq.headN)
+	s = sq.appendCache(s)
+	s = appendInnerOf(s, sq.tail, sq.tailF, sq.tailN)
+
+	return s
+}
+
+// String - string representation: formats elements in queue order as string
+// Relies on Each() method to load values from memory - O(n)
+func (sq *SqueueOf[T]) String() string {
+	return fmt.Sprint(sq.Each())
+}
+
+/* Internals */
+
+// Resize slice to double the number of elements in the queue
+// For small n, place values at beginning of larger slice to prevent unnecessary allocations
+func (sq *SqueueOf[T]) grow() {
+	n := cap(sq.cache)
+	if n < 6 {
+		sq.resize(8)
+	} else {
+		sq.resize(2 * n)
+	}
+}
+
+// Allocates bigger cache slices, copies elements from old onto new
+// Slice is in circular order, and are reset to 0th index
+// Reconfigures pointers to reflect shift
+// Only the slots in use, head to tail, are copied; m is raised to their
+// number if smaller, as for Squeue.resize
+func (sq *SqueueOf[T]) resize(m int) {
+	n, lenQ := sq.liveSlots(), len(sq.cache)
+	m = max(m, max(n, 2))
+	// Allocate new cache slices
+	qq := make([]*CachedOf[T], m)
+	// Copy slots in use to beginning of new slice, head first
+	for k := 0; k < n; k++ {
+		qq[k] = sq.cache[(sq.cacheF+k)%lenQ]
+	}
+	// Set pointers; cacheL wraps to cacheF if the new cache is full
+	sq.cacheF = 0
+	sq.cacheL = n % m
+	// Set underlying slice as newly allocated slice
+	sq.cache = qq
+}
+
+// Moves the head forward past emptied slices until it holds the first elem, and returns it
+// Returns false if the queue is empty
+// Voids cached slices no longer in use; used by delete operations at the front
+func (sq *SqueueOf[T]) loadFront() (T, bool) {
+	// Return elem if head holds one
+	if sq.headN > 0 {
+		return sq.head[sq.headF], true
+	}
+	// No elems in head; move to next slice in cache until only head left
+	d1, d2 := (sq.cacheF - 1), ((sq.cacheF + 1) % len(sq.cache))
+	if d1 < 0 {
+		d1 += len(sq.cache)
+	}
+	// Check the last queue has been used
+	if d2 == sq.cacheL {
+		var zero T
+		return zero, false
+	}
+	// Void cached slice pointer if not in use
+	if sq.cacheF != sq.cacheL && d1 != sq.cacheL {
+		sq.cache[d1] = nil
+	}
+	// Inc outer head pointer
+	sq.cacheF = d2
+	// Get next slice
+	if ((sq.cacheF + 1) % len(sq.cache)) == sq.cacheL {
+		// Only one slice remains: the head should take the tail's place, and tail should be void
+		sq.head = sq.tail
+		sq.headF, sq.headL, sq.headN = sq.tailF, sq.tailL, sq.tailN
+		sq.tail = nil
+		sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	} else {
+		// Pointer taken from cache; dereference, and use as head
+		sq.head = (*sq.cache[sq.cacheF].ptr)
+		sq.cacheSize -= len(sq.head)
+		hF := sq.cache[sq.cacheF].idx
+		sq.headF, sq.headL, sq.headN = hF, hF, len(sq.head)
+	}
+	// Head is empty; recurse until elem is found or cache is empty
+	return sq.loadFront()
+}
+
+// Moves the tail backward past emptied slices until it, or the head if no tail remains, holds the last elem, and returns it
+// Returns false if the queue is empty
+// Voids cached slices no longer in use; used by delete operations at the back
+func (sq *SqueueOf[T]) loadBack() (T, bool) {
+	d1 := sq.cacheF - 1
+	if d1 < 0 {
+		d1 += len(sq.cache)
+	}
+	if sq.tail == nil {
+		// Perform operation on head slice
+		if sq.headN > 0 {
+			return sq.head[(sq.headL-1+len(sq.head))%len(sq.head)], true
+		}
+		var zero T
+		return zero, false
+	}
+	// Perform operation on tail slice
+	if sq.tailN > 0 {
+		return sq.tail[(sq.tailL-1+len(sq.tail))%len(sq.tail)], true
+	}
+	// No elems in tail; move to next slice in cache until only head left
+	d2, d3 := sq.cacheL-1, sq.cacheL-2
+	if d2 < 0 {
+		d2 += len(sq.cache)
+	}
+	if d3 < 0 {
+		d3 += len(sq.cache)
+	}
+	// Void cached slice pointer if not in use
+	if sq.cacheL != sq.cacheF && sq.cacheL != d1 {
+		sq.cache[sq.cacheL] = nil
+	}
+	// Dec outer tail pointer
+	sq.cacheL = d2
+	if sq.cacheL == ((sq.cacheF + 1) % len(sq.cache)) {
+		// Last slice in cache, set tail to nil
+		sq.tail = nil
+		sq.tailF, sq.tailL, sq.tailN = 0, 0, 0
+	} else {
+		// Take pointer from cache, dereference, use as tail
+		sq.tail = (*sq.cache[d3].ptr)
+		tF := sq.cache[d3].idx
+		sq.tailF, sq.tailL, sq.tailN = tF, tF, len(sq.tail)
+		sq.cacheSize -= len(sq.tail)
+	}
+	// Tail is empty; recurse until elem is found or cache is empty
+	return sq.loadBack()
+}
+
+// Returns the number of cache slots in use, from the head slot to the tail slot inclusive
+func (sq *SqueueOf[T]) liveSlots() int {
+	switch {
+	case sq.tail == nil:
+		return 1
+	case sq.cacheF == sq.cacheL:
+		return len(sq.cache)
+	default:
+		return (sq.cacheL - sq.cacheF + len(sq.cache)) % len(sq.cache)
+	}
+}
+
+// Resolve logical index i to the inner slice holding it and the element's slot in that slice
+// Caller must ensure 0 <= i < Size()
+func (sq *SqueueOf[T]) locate(i int) ([]T, int) {
+	// Index falls in head slice
+	if i < sq.headN {
+		return sq.head, (sq.headF + i) % len(sq.head)
+	}
+	i -= sq.headN
+	// Index falls in a cached slice; cached slices are full, so skip by length
+	if i < sq.cacheSize {
+		lenQ := len(sq.cache)
+		for c := (sq.cacheF + 1) % lenQ; ; c = (c + 1) % lenQ {
+			q := *(sq.cache[c].ptr)
+			if i < len(q) {
+				return q, (sq.cache[c].idx + i) % len(q)
+			}
+			i -= len(q)
+		}
+	}
+	// Index falls in tail slice
+	i -= sq.cacheSize
+	return sq.tail, (sq.tailF + i) % len(sq.tail)
+}
+
+// Get slices from references in cache between head and tail, then add their values in their queue order to a slice. Return the slice
+func (sq *SqueueOf[T]) appendCache(s []T) []T {
+	if sq.tail == nil {
+		return s
+	}
+	lenQ := len(sq.cache)
+	last := (sq.cacheL - 1 + lenQ) % lenQ
+	for i := (sq.cacheF + 1) % lenQ; i != last; i = (i + 1) % lenQ {
+		q := *(sq.cache[i].ptr)
+		s = appendInnerOf(s, q, sq.cache[i].idx, len(q))
+	}
+	return s
+}
+
+// Add n values from circular slice q, starting at index p, into slice in queue order, return slice
+func appendInnerOf[T any](s []T, q []T, p, n int) []T {
+	lenq := len(q)
+	for j := 0; j < n; j++ {
+		s = append(s, q[(p+j)%lenq])
+	}
+	return s
+}
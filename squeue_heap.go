@@ -0,0 +1,54 @@
+package squeue
+
+// container/heap support
+//
+// HeapAdapter lets a Squeue serve as the backing store of container/heap.
+// The heap algorithms address elements by index, and each index access on a
+// Squeue steps over the inner slices (see At), costing O(k) for k slices
+// rather than the O(1) of a plain slice. heap.Push and heap.Pop are thus
+// O(k log n); for large heaps, ShrinkToFit before heapifying keeps k at 1
+// until the queue grows past its head slice.
+
+/* Data Types */
+
+// HeapAdapter: implements heap.Interface over a Squeue
+type HeapAdapter struct {
+	sq   *Squeue                     // Backing queue; the front is the heap root
+	less func(a, b interface{}) bool // Ordering of elements; the root is the smallest
+}
+
+/* Exports */
+
+// NewHeapAdapter - heap.Interface constructor over sq, ordered by less
+// Call heap.Init on the result before other heap operations if sq is not empty
+func NewHeapAdapter(sq *Squeue, less func(a, b interface{}) bool) *HeapAdapter {
+	return &HeapAdapter{sq, less}
+}
+
+// Len - returns number of elements in the heap
+func (h *HeapAdapter) Len() int {
+	return h.sq.Size()
+}
+
+// Less - reports whether element at index i orders before element at index j
+func (h *HeapAdapter) Less(i, j int) bool {
+	a, _ := h.sq.At(i)
+	b, _ := h.sq.At(j)
+	return h.less(a, b)
+}
+
+// Swap - exchange elements at indices i and j
+func (h *HeapAdapter) Swap(i, j int) {
+	h.sq.Swap(i, j)
+}
+
+// Push - add element to back of queue; called by heap.Push
+func (h *HeapAdapter) Push(x interface{}) {
+	h.sq.Push(x)
+}
+
+// Pop - remove element from back of queue; called by heap.Pop
+func (h *HeapAdapter) Pop() interface{} {
+	elem, _ := h.sq.TryPop()
+	return elem
+}
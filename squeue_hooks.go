@@ -0,0 +1,43 @@
+package squeue
+
+// Hooks
+//
+// Callbacks fired as single elements enter and leave the queue, e.g. to keep
+// metrics or to close resources evicted from a ring. OnAdd fires from Push
+// and Shift; OnRemove from Pop and Unshift, and their Try variants. Methods
+// built on these fire them too (PushAll, PopN, KeepLast, ring eviction, ...),
+// as do InsertAt, RemoveAt and the Dedup methods, with the element actually
+// added or removed. Bulk resets (Clear, Drain, Restore, decoding),
+// reordering (Sort, Reverse, Swap, RotateToFront) and Split, which only moves elements to a
+// queue sharing the hooks, do not fire them.
+//
+// Hooks run after the queue has been updated, and must not add to or remove
+// from it. Clone shares the hooks of the original.
+
+// OnAdd - set fn to be called with each element added to the queue
+// Replaces any previous hook; nil removes it
+func (sq *Squeue) OnAdd(fn func(interface{})) {
+	sq.onAdd = fn
+	sq.setExtras()
+}
+
+// OnRemove - set fn to be called with each element removed from the queue
+// Replaces any previous hook; nil removes it. For a ring, evicted elements are
+// reported before the element that evicted them is added
+func (sq *Squeue) OnRemove(fn func(interface{})) {
+	sq.onRemove = fn
+	sq.setExtras()
+}
+
+/* Internals */
+
+// Calls fn with the hooks unset, for operations that add or remove placeholder elements
+// The caller reports the elements really added or removed itself
+func (sq *Squeue) quietly(fn func()) {
+	onAdd, onRemove := sq.onAdd, sq.onRemove
+	sq.onAdd, sq.onRemove = nil, nil
+	defer func() {
+		sq.onAdd, sq.onRemove = onAdd, onRemove
+	}()
+	fn()
+}
@@ -0,0 +1,18 @@
+package squeue
+
+// Int queues
+//
+// IntSqueue is the generic queue instantiated for int, rather than a separate
+// copy of the implementation: SqueueOf already stores elements in []T slices
+// and tracks occupancy with counts, so ints are neither boxed into
+// interface{} nor kept apart from 0 by a sentinel.
+
+// IntSqueue - double-ended queue of ints, backed by []int slices
+// Has the methods of SqueueOf; e.g. Pop returns an int without a type assertion
+type IntSqueue = SqueueOf[int]
+
+// NewInt - int queue constructor
+// Accepts initial values to be enqueued, in the order listed
+func NewInt(initial ...int) IntSqueue {
+	return NewOf(initial...)
+}
@@ -0,0 +1,182 @@
+package squeue
+
+import (
+	"iter"
+)
+
+// Iterators for range-over-func (Go 1.23+)
+//
+// The iterators read the inner slices in place. Like the peeks, and unlike
+// the delete operations, which advance past emptied slices, they never move
+// the head/tail/cache pointers, so breaking out of a loop early leaves the
+// queue exactly as it was.
+
+// All - iterator over elements, front to back
+// Ex.
+//
+//	for elem := range qq.All() {
+//		// do something with elem
+//	}
+func (sq *Squeue) All() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		sq.walk(func(_ int, elem interface{}) bool {
+			return yield(elem)
+		})
+	}
+}
+
+// All2 - iterator over logical indices and elements, front to back
+// Ex.
+//
+//	for i, elem := range qq.All2() {
+//		// do something with i, elem
+//	}
+func (sq *Squeue) All2() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		sq.walk(yield)
+	}
+}
+
+// Backward - iterator over elements, back to front
+// Walks the tail, then the cached slices in reverse, then the head
+// Ex.
+//
+//	for elem := range qq.Backward() {
+//		// most recently pushed elem first
+//	}
+func (sq *Squeue) Backward() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		sq.walkBack(func(_ int, elem interface{}) bool {
+			return yield(elem)
+		})
+	}
+}
+
+// Collect - queue constructor from an iterator, holding the elements in the order yielded
+// The counterpart of All: Collect(qq.All()) is a copy of qq. The new queue
+// is unbounded, so unlike PushAll it never panics with ErrFull
+// Ex.
+//
+//	evens := Collect(func(yield func(interface{}) bool) {
+//		for i := 0; i < 10 && yield(2*i); i++ {
+//		}
+//	})
+func Collect(seq iter.Seq[interface{}]) Squeue {
+	sq := New()
+	for elem := range seq {
+		sq.Push(elem)
+	}
+	return sq
+}
+
+/* Internals */
+
+// Calls fn on each element in queue order along with its logical index, until fn returns false
+// Slices are read in place; no pointers are moved
+func (sq *Squeue) walk(fn func(i int, elem interface{}) bool) {
+	sq.walkSlots(func(i int, q []interface{}, j int) bool {
+		return fn(i, q[j])
+	})
+}
+
+// Calls fn with the logical index of each element in queue order, and the inner slice and slot holding it
+// Lets callers overwrite elements in place; no pointers are moved
+func (sq *Squeue) walkSlots(fn func(i int, q []interface{}, j int) bool) {
+	i := 0
+	// Visit n elements of circular slice q, starting at index p
+	visit := func(q []interface{}, p, n int) bool {
+		lenq := len(q)
+		for k := 0; k < n; k++ {
+			if !fn(i, q, (p+k)%lenq) {
+				return false
+			}
+			i++
+		}
+		return true
+	}
+	if !visit(sq.head, sq.headF, sq.headN) {
+		return
+	}
+	// Cached slices lie strictly between the head and tail slots
+	if sq.tail != nil {
+		lenQ := len(sq.cache)
+		last := (sq.cacheL - 1 + lenQ) % lenQ
+		for c := (sq.cacheF + 1) % lenQ; c != last; c = (c + 1) % lenQ {
+			q := *(sq.cache[c].ptr)
+			if !visit(q, sq.cache[c].idx, len(q)) {
+				return
+			}
+		}
+	}
+	visit(sq.tail, sq.tailF, sq.tailN)
+}
+
+// Calls fn on each contiguous run of elements in queue order, until fn returns false
+// A circular inner slice yields up to two runs; runs alias the inner slices
+func (sq *Squeue) walkRuns(fn func(run []interface{}) bool) {
+	// Visit n elements of circular slice q, starting at index p
+	visit := func(q []interface{}, p, n int) bool {
+		if n == 0 {
+			return true
+		}
+		end := min(p+n, len(q))
+		if !fn(q[p:end]) {
+			return false
+		}
+		return end-p == n || fn(q[:n-(end-p)])
+	}
+	if !visit(sq.head, sq.headF, sq.headN) {
+		return
+	}
+	if sq.tail != nil {
+		lenQ := len(sq.cache)
+		last := (sq.cacheL - 1 + lenQ) % lenQ
+		for c := (sq.cacheF + 1) % lenQ; c != last; c = (c + 1) % lenQ {
+			q := *(sq.cache[c].ptr)
+			if !visit(q, sq.cache[c].idx, len(q)) {
+				return
+			}
+		}
+	}
+	visit(sq.tail, sq.tailF, sq.tailN)
+}
+
+// Calls fn on each element in reverse queue order along with its logical index, until fn returns false
+// Slices are read in place; no pointers are moved
+func (sq *Squeue) walkBack(fn func(i int, elem interface{}) bool) {
+	sq.walkBackSlots(func(i int, q []interface{}, j int) bool {
+		return fn(i, q[j])
+	})
+}
+
+// Calls fn with the logical index of each element in reverse queue order, and the inner slice and slot holding it
+// Lets callers overwrite elements in place; no pointers are moved
+func (sq *Squeue) walkBackSlots(fn func(i int, q []interface{}, j int) bool) {
+	i := sq.Size() - 1
+	// Visit n elements of circular slice q backwards, starting at the index before l
+	visit := func(q []interface{}, l, n int) bool {
+		lenq := len(q)
+		for k := 1; k <= n; k++ {
+			if !fn(i, q, ((l-k)%lenq+lenq)%lenq) {
+				return false
+			}
+			i--
+		}
+		return true
+	}
+	if !visit(sq.tail, sq.tailL, sq.tailN) {
+		return
+	}
+	// Cached slices lie strictly between the tail and head slots; a full
+	// cached slice ends just before the index of its first element
+	if sq.tail != nil {
+		lenQ := len(sq.cache)
+		for c := (sq.cacheL - 2 + lenQ) % lenQ; c != sq.cacheF; c = (c - 1 + lenQ) % lenQ {
+			q := *(sq.cache[c].ptr)
+			if !visit(q, sq.cache[c].idx, len(q)) {
+				return
+			}
+		}
+	}
+	visit(sq.head, sq.headL, sq.headN)
+}
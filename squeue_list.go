@@ -0,0 +1,30 @@
+package squeue
+
+import (
+	"container/list"
+)
+
+// container/list interoperability
+//
+// Conversions between Squeue and the doubly-linked list of container/list,
+// for code migrating between the two one piece at a time. Both directions
+// copy the elements, front to back; the source is left unchanged.
+
+// ToList - returns a new list.List holding the elements of sq, front to back
+func ToList(sq *Squeue) *list.List {
+	l := list.New()
+	sq.walk(func(_ int, elem interface{}) bool {
+		l.PushBack(elem)
+		return true
+	})
+	return l
+}
+
+// FromList - returns a new queue holding the elements of l, front to back
+func FromList(l *list.List) Squeue {
+	sq := New()
+	for e := l.Front(); e != nil; e = e.Next() {
+		sq.Push(e.Value)
+	}
+	return sq
+}
@@ -0,0 +1,107 @@
+package squeue
+
+// Constructor options
+//
+// New sizes the first inner slice for the initial values given (2*max(n, 10))
+// and starts with a cache of 6 slots. NewWithOptions lets callers choose those
+// sizes for their workload instead; both grow on demand afterwards, as in any
+// other queue, so the options only decide where growth starts.
+//
+// Each new inner slice doubles the length of the largest in use, up to a
+// maximum. A larger maximum means fewer slices for very large queues; a
+// smaller one bounds the memory a single slice holds on to.
+//
+// The factor of 2, also used when the cache is reallocated, can be lowered:
+// at 1.5, a growing queue leaves at most about a third of its newest slice
+// unused rather than half, but allocates more often, making more and smaller
+// slices and more cache reallocations along the way. The cap applies
+// to slices allocated as the queue grows: ShrinkToFit, Restore and decoding
+// still gather all elements into one slice.
+
+// Maximum length of inner slices allocated as a queue grows, unless set by WithMaxInnerCap
+const defaultMaxInner = 100000
+
+// Option - configures a queue built by NewWithOptions
+type Option func(*options)
+
+// Settings chosen by the options
+type options struct {
+	initialCap int     // Length of the first (head) inner slice
+	cacheSize  int     // Number of slots in the cache of inner slices
+	maxInner   int     // Maximum length of an inner slice
+	growth     float64 // Factor by which inner slices and the cache grow; 0 for doubling
+	compact    float64 // Ratio of capacity to size above which removals compact; 0 if off
+}
+
+// WithInitialCapacity - the first inner slice holds n elements before another is allocated
+// Panics if n < 1
+func WithInitialCapacity(n int) Option {
+	if n < 1 {
+		panic("squeue: invalid initial capacity")
+	}
+	return func(o *options) {
+		o.initialCap = n
+	}
+}
+
+// WithCacheSize - the cache starts with n slots, for up to n inner slices before it is reallocated
+// Panics if n < 2; the head and tail slices each need a slot
+func WithCacheSize(n int) Option {
+	if n < 2 {
+		panic("squeue: invalid cache size")
+	}
+	return func(o *options) {
+		o.cacheSize = n
+	}
+}
+
+// WithMaxInnerCap - inner slices hold at most n elements; defaults to 100000
+// Also caps the first inner slice, overriding a larger WithInitialCapacity
+// Panics if n < 1
+func WithMaxInnerCap(n int) Option {
+	if n < 1 {
+		panic("squeue: invalid maximum inner capacity")
+	}
+	return func(o *options) {
+		o.maxInner = n
+	}
+}
+
+// WithGrowthFactor - new inner slices, and the cache when reallocated, grow by a factor of f instead of 2
+// Panics unless f > 1
+func WithGrowthFactor(f float64) Option {
+	if !(f > 1) {
+		panic("squeue: invalid growth factor")
+	}
+	return func(o *options) {
+		o.growth = f
+	}
+}
+
+// WithAutoCompact - removals compact the queue once Cap() exceeds ratio times Size()
+// Off by default. A compacted queue keeps room for up to twice its size, so
+// panics if ratio < 2; sizes below 10 are treated as 10
+func WithAutoCompact(ratio float64) Option {
+	if !(ratio >= 2) {
+		panic("squeue: invalid auto compaction ratio")
+	}
+	return func(o *options) {
+		o.compact = ratio
+	}
+}
+
+// NewWithOptions - empty queue constructor, configured by opts in the order given
+// Ex. NewWithOptions(WithInitialCapacity(1024), WithCacheSize(16), WithMaxInnerCap(4096))
+func NewWithOptions(opts ...Option) Squeue {
+	// Sizes New() uses when given no initial values
+	o := options{initialCap: 20, cacheSize: 6, maxInner: defaultMaxInner}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	head, cache := make([]interface{}, min(o.initialCap, o.maxInner)), make([]*Cached, o.cacheSize)
+	cache[0] = &Cached{&head, 0}
+
+	sq := Squeue{head: head, cache: cache, cacheL: 1, maxInner: o.maxInner, growth: o.growth, autoCompact: o.compact}
+	sq.setExtras()
+	return sq
+}
@@ -0,0 +1,79 @@
+package squeue
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Slice pooling
+//
+// Under heavy churn, inner slices are discarded as the queue shrinks and
+// allocated again as it grows. A pooled queue instead releases discarded
+// inner slices, with their Cached entries, to a sync.Pool shared by all
+// pooled queues, and takes new inner slices from it.
+//
+// A slice is only released once no slot of the cache refers to it and every
+// element in it has been voided, so a slice taken from the pool is always
+// empty and never aliased by another queue or another slot. Clone, ToSlice
+// and the iterators never hand inner slices to callers, which keeps that true.
+//
+// Pooled queues round the length of each new inner slice up to a power of
+// two, so there is one pool per power and the pools stay few however many
+// different lengths are requested. Slices of other lengths, such as the
+// initial head, are left to the garbage collector when discarded.
+
+// Pools of *Cached entries, indexed by the base 2 logarithm of their slice's length
+var innerPools [bits.UintSize]sync.Pool
+
+// NewPooled - queue constructor for a queue that recycles its inner slices
+// Accepts initial values to be enqueued, in the order listed
+func NewPooled(initial ...interface{}) Squeue {
+	sq := New(initial...)
+	sq.pooled = true
+	return sq
+}
+
+/* Internals */
+
+// Returns a cache entry holding an empty inner slice of length n, from the pool if the queue is pooled
+// A pooled queue's slice is n rounded up to a power of two
+func (sq *Squeue) newInner(n int) *Cached {
+	if sq.pooled {
+		n = 1 << bits.Len(uint(n-1))
+		if c, ok := innerPool(n).Get().(*Cached); ok {
+			c.idx = 0
+			return c
+		}
+	}
+	inner := make([]interface{}, n)
+	return &Cached{&inner, 0}
+}
+
+// Releases a discarded cache entry to the pool if the queue is pooled and its length is a power of two
+// The entry's slice must hold no elements, and no slot of the cache may refer to it afterward
+func (sq *Squeue) release(c *Cached) {
+	if sq.pooled && c != nil {
+		if n := len(*c.ptr); n > 0 && n&(n-1) == 0 {
+			innerPool(n).Put(c)
+		}
+	}
+}
+
+// Voids and releases every slice in the cache to the pool if the queue is pooled
+// Used when the whole structure is replaced; the cache must not be used afterward
+func (sq *Squeue) releaseAll() {
+	if !sq.pooled {
+		return
+	}
+	for _, c := range sq.cache {
+		if c != nil {
+			clear(*c.ptr)
+			sq.release(c)
+		}
+	}
+}
+
+// Returns the pool for inner slices of length n, a power of two
+func innerPool(n int) *sync.Pool {
+	return &innerPools[bits.TrailingZeros(uint(n))]
+}
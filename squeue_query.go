@@ -0,0 +1,188 @@
+package squeue
+
+// Queries
+//
+// Read-only scans over the elements, front to back unless stated otherwise.
+// Like the peeks, they traverse the inner slices in place and never move the
+// head/tail/cache pointers, so they are safe under SyncSqueue's read lock.
+//
+// Methods comparing with == follow the language rules for interface values:
+// comparing two elements of the same non-comparable type (maps, slices,
+// funcs) panics. Use the Func variants for such elements.
+
+// Contains - returns true if any element == elem
+// Stops at the first match
+func (sq *Squeue) Contains(elem interface{}) bool {
+	return sq.ContainsFunc(func(v interface{}) bool {
+		return v == elem
+	})
+}
+
+// ContainsFunc - returns true if pred returns true for any element
+// Stops at the first match
+func (sq *Squeue) ContainsFunc(pred func(interface{}) bool) bool {
+	found := false
+	sq.walk(func(_ int, v interface{}) bool {
+		found = pred(v)
+		return !found
+	})
+	return found
+}
+
+// Count - returns the number of elements for which pred returns true
+func (sq *Squeue) Count(pred func(interface{}) bool) int {
+	n := 0
+	sq.walk(func(_ int, v interface{}) bool {
+		if pred(v) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Any - returns true if pred returns true for any element; false for an empty queue
+// Same as ContainsFunc; stops at the first match
+func (sq *Squeue) Any(pred func(interface{}) bool) bool {
+	return sq.ContainsFunc(pred)
+}
+
+// Every - returns true if pred returns true for every element; true for an empty queue
+// Stops at the first element failing pred. Not named All, which is the iterator
+func (sq *Squeue) Every(pred func(interface{}) bool) bool {
+	return !sq.ContainsFunc(func(v interface{}) bool {
+		return !pred(v)
+	})
+}
+
+// IndexOf - returns logical index of the first element == elem, or -1 if absent
+// Front of queue is index 0
+func (sq *Squeue) IndexOf(elem interface{}) int {
+	res := -1
+	sq.walk(func(i int, v interface{}) bool {
+		if v == elem {
+			res = i
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+// LastIndexOf - returns logical index of the last element == elem, or -1 if absent
+// Searches back to front, so stops at the match nearest the back
+func (sq *Squeue) LastIndexOf(elem interface{}) int {
+	res := -1
+	sq.walkBack(func(i int, v interface{}) bool {
+		if v == elem {
+			res = i
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+// Equal - returns true if both queues hold the same number of elements, and each pair in order is ==
+func (sq *Squeue) Equal(other *Squeue) bool {
+	return sq.EqualFunc(other, func(a, b interface{}) bool {
+		return a == b
+	})
+}
+
+// EqualFunc - returns true if both queues hold the same number of elements, and eq holds for each pair in order
+// Use for elements that are not comparable with ==
+func (sq *Squeue) EqualFunc(other *Squeue, eq func(a, b interface{}) bool) bool {
+	if sq.Size() != other.Size() {
+		return false
+	}
+	s, res := other.ToSlice(), true
+	sq.walk(func(i int, v interface{}) bool {
+		res = eq(v, s[i])
+		return res
+	})
+	return res
+}
+
+// Min - returns the smallest element according to less
+// Of several equally small elements, the one nearest the front is returned
+// Returns ErrEmpty if the queue is empty
+func (sq *Squeue) Min(less func(a, b interface{}) bool) (interface{}, error) {
+	if sq.Empty() {
+		return nil, ErrEmpty
+	}
+	var res interface{}
+	sq.walk(func(i int, v interface{}) bool {
+		if i == 0 || less(v, res) {
+			res = v
+		}
+		return true
+	})
+	return res, nil
+}
+
+// Max - returns the largest element according to less
+// Of several equally large elements, the one nearest the front is returned
+// Returns ErrEmpty if the queue is empty
+func (sq *Squeue) Max(less func(a, b interface{}) bool) (interface{}, error) {
+	if sq.Empty() {
+		return nil, ErrEmpty
+	}
+	var res interface{}
+	sq.walk(func(i int, v interface{}) bool {
+		if i == 0 || less(res, v) {
+			res = v
+		}
+		return true
+	})
+	return res, nil
+}
+
+// Fold - combines the elements front to back, starting from init
+// Each call gets the previous result and the next element; returns init if the queue is empty
+// Ex. Fold(0, func(acc, v interface{}) interface{} { return acc.(int) + v.(int) })
+func (sq *Squeue) Fold(init interface{}, fn func(acc, elem interface{}) interface{}) interface{} {
+	acc := init
+	sq.walk(func(_ int, v interface{}) bool {
+		acc = fn(acc, v)
+		return true
+	})
+	return acc
+}
+
+// IsSorted - returns true if the queue is sorted according to cmp, i.e. no element is less than the one before it
+// cmp is as for Search. Stops at the first pair out of order; empty and
+// single-element queues are sorted
+func (sq *Squeue) IsSorted(cmp func(a, b interface{}) int) bool {
+	sorted := true
+	var prev interface{}
+	sq.walk(func(i int, v interface{}) bool {
+		sorted = i == 0 || cmp(prev, v) <= 0
+		prev = v
+		return sorted
+	})
+	return sorted
+}
+
+// Search - binary search for target in a queue sorted according to cmp
+// cmp(a, b) returns a negative number if a < b, zero if a == b, and a positive
+// number if a > b. Returns the index of the first element not less than
+// target, where target is or would be placed, and whether it was found there.
+// O(k log n) for k inner slices; the result is undefined if the queue is not sorted
+func (sq *Squeue) Search(target interface{}, cmp func(a, b interface{}) int) (int, bool) {
+	lo, hi := 0, sq.Size()
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		q, j := sq.locate(mid)
+		if cmp(q[j], target) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == sq.Size() {
+		return lo, false
+	}
+	q, j := sq.locate(lo)
+	return lo, cmp(q[j], target) == 0
+}
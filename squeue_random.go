@@ -0,0 +1,54 @@
+package squeue
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Randomness
+//
+// Operations drawing on a caller-supplied *rand.Rand, so that results are
+// reproducible under a fixed seed.
+
+// Shuffle - randomly permute elements in place, using rng
+// Fisher–Yates over logical indices: each element is swapped with one at a
+// random index no further back. O(n·k) for k inner slices
+func (sq *Squeue) Shuffle(rng *rand.Rand) {
+	for i := sq.Size() - 1; i > 0; i-- {
+		sq.Swap(i, rng.Intn(i+1))
+	}
+}
+
+// Sample - retrieve a uniformly random element without removing it, using rng
+// Returns ErrEmpty if the queue is empty
+func (sq *Squeue) Sample(rng *rand.Rand) (interface{}, error) {
+	if sq.Empty() {
+		return nil, ErrEmpty
+	}
+	q, j := sq.locate(rng.Intn(sq.Size()))
+	return q[j], nil
+}
+
+// SampleN - retrieve n elements at distinct random indices without removing them, using rng
+// Every set of n indices is equally likely (Floyd's algorithm); the elements
+// are returned in the order drawn. Errors if n is negative or exceeds Size()
+func (sq *Squeue) SampleN(n int, rng *rand.Rand) ([]interface{}, error) {
+	size := sq.Size()
+	if n < 0 {
+		return nil, fmt.Errorf("negative count %d", n)
+	}
+	if n > size {
+		return nil, fmt.Errorf("count %d exceeds size %d", n, size)
+	}
+	res, drawn := make([]interface{}, 0, n), make(map[int]bool, n)
+	for j := size - n; j < size; j++ {
+		i := rng.Intn(j + 1)
+		if drawn[i] {
+			i = j
+		}
+		drawn[i] = true
+		q, k := sq.locate(i)
+		res = append(res, q[k])
+	}
+	return res, nil
+}
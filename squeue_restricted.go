@@ -0,0 +1,83 @@
+package squeue
+
+// Restricted queues
+//
+// Queue and Stack wrap a Squeue, exposing only the operations of a FIFO queue
+// or a LIFO stack, so that callers cannot reach the other end by mistake.
+// Elements are added at the back of the underlying queue in both cases.
+
+/* Data Types */
+
+// Queue: first-in, first-out wrapper around Squeue
+type Queue struct {
+	sq Squeue // Wrapped queue; front is the oldest element
+}
+
+// Stack: last-in, first-out wrapper around Squeue
+type Stack struct {
+	sq Squeue // Wrapped queue; back is the top of the stack
+}
+
+/* Exports */
+
+// NewQueue - FIFO queue constructor
+// Accepts initial values to be enqueued, in the order listed; the first is removed first
+func NewQueue(initial ...interface{}) *Queue {
+	return &Queue{sq: New(initial...)}
+}
+
+// Push - add to back of queue (enqueue)
+func (q *Queue) Push(elem interface{}) {
+	q.sq.Push(elem)
+}
+
+// Unshift - remove element from front of queue (dequeue)
+func (q *Queue) Unshift() (interface{}, error) {
+	return q.sq.Unshift()
+}
+
+// Peek - retrieve the element Unshift would remove, without removing it
+func (q *Queue) Peek() (interface{}, error) {
+	return q.sq.PeekFront()
+}
+
+// Size - returns number of elements in queue
+func (q *Queue) Size() int {
+	return q.sq.Size()
+}
+
+// Returns true if queue is empty
+func (q *Queue) Empty() bool {
+	return q.sq.Empty()
+}
+
+// NewStack - LIFO stack constructor
+// Accepts initial values to be pushed, in the order listed; the last is on top
+func NewStack(initial ...interface{}) *Stack {
+	return &Stack{sq: New(initial...)}
+}
+
+// Push - add to top of stack
+func (s *Stack) Push(elem interface{}) {
+	s.sq.Push(elem)
+}
+
+// Pop - remove element from top of stack
+func (s *Stack) Pop() (interface{}, error) {
+	return s.sq.Pop()
+}
+
+// Peek - retrieve the element Pop would remove, without removing it
+func (s *Stack) Peek() (interface{}, error) {
+	return s.sq.PeekBack()
+}
+
+// Size - returns number of elements in stack
+func (s *Stack) Size() int {
+	return s.sq.Size()
+}
+
+// Returns true if stack is empty
+func (s *Stack) Empty() bool {
+	return s.sq.Empty()
+}
@@ -0,0 +1,34 @@
+package squeue
+
+// Snapshots
+//
+// A snapshot records the logical contents of a queue, front to back, so the
+// queue can later be rolled back to them. Only elements are recorded, not
+// the slice layout; Restore rebuilds the structure from scratch.
+
+/* Data Types */
+
+// Snapshot: point-in-time copy of a queue's elements
+type Snapshot struct {
+	elems []interface{} // Elements in queue order, front first
+}
+
+/* Exports */
+
+// Snapshot - capture the current elements of the queue
+// Elements are copied shallowly; later changes to the queue do not affect the snapshot
+func (sq *Squeue) Snapshot() Snapshot {
+	return Snapshot{sq.ToSlice()}
+}
+
+// Restore - replace the elements of the queue with those recorded in s
+// A bound set on the queue is kept; returns ErrFull, leaving the queue
+// unchanged, if s holds more elements than the bound allows
+func (sq *Squeue) Restore(s Snapshot) error {
+	return sq.load(s.elems)
+}
+
+// Size - returns number of elements recorded in the snapshot
+func (s Snapshot) Size() int {
+	return len(s.elems)
+}
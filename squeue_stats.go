@@ -0,0 +1,58 @@
+package squeue
+
+// Statistics
+//
+// Figures describing the memory held by a queue, computed from the existing
+// pointers and slice lengths; nothing is tracked beyond what the queue
+// already records.
+//
+// Metrics, by contrast, are counters kept as the queue is used, describing
+// the shape of the workload: how often each end is added to and removed from.
+
+/* Data Types */
+
+// Stats: allocation statistics of a queue
+type Stats struct {
+	Slices        int // Number of inner slices in use: head, cached slices, tail, and any waiting for the next push
+	Capacity      int // Total length of the inner slices in use; see Cap
+	Size          int // Number of elements; see Size
+	Fragmentation int // Slots allocated but not holding an element; Capacity - Size
+}
+
+// Metrics: operation counts of a queue
+// Operations made by other methods are included, e.g. PushAll counts one push
+// per element, and a ring's evictions count as pops or unshifts
+type Metrics struct {
+	Pushes   int // Elements added by Push
+	Shifts   int // Elements added by Shift
+	Pops     int // Elements removed by Pop and TryPop
+	Unshifts int // Elements removed by Unshift and TryUnshift
+	Grows    int // Reallocations of the cache to hold more inner slices
+}
+
+/* Exports */
+
+// Stats - returns allocation statistics of the queue
+// O(k) for k inner slices
+func (sq *Squeue) Stats() Stats {
+	c, n, k := sq.Cap(), sq.Size(), sq.liveSlots()
+	if sq.reserved() != nil {
+		k++
+	}
+	return Stats{
+		Slices:        k,
+		Capacity:      c,
+		Size:          n,
+		Fragmentation: c - n,
+	}
+}
+
+// Metrics - returns operation counts since the queue was created or ResetMetrics was called
+func (sq *Squeue) Metrics() Metrics {
+	return sq.metrics
+}
+
+// ResetMetrics - sets all operation counts to zero
+func (sq *Squeue) ResetMetrics() {
+	sq.metrics = Metrics{}
+}
@@ -0,0 +1,148 @@
+package squeue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// SyncSqueue - Squeue that is safe for concurrent use by multiple goroutines
+//
+// Every other method takes the embedded lock for the duration of the
+// underlying Squeue call. Adds and deletes take the write lock; the peeks do
+// not modify the queue, so they share a read lock.
+//
+// PopWait blocks on a ready channel rather than a sync.Cond, so that it can
+// also select on a context. The channel is made by the first waiter and
+// closed by the next add, which wakes every waiter to retry.
+//
+// Size and Empty take no lock at all: every add and delete stores the new
+// size in an atomic counter before releasing the write lock, so goroutines
+// monitoring the queue never contend with those using it.
+
+/* Data Types */
+
+// SyncSqueue: concurrency-safe wrapper around Squeue
+type SyncSqueue struct {
+	mu    sync.RWMutex  // Guards sq and ready; write lock for anything that modifies them
+	sq    Squeue        // Wrapped queue
+	ready chan struct{} // Closed when an element is added; nil if no goroutine is waiting
+	n     atomic.Int64  // Number of elements in sq, stored after each add and delete
+}
+
+/* Exports */
+
+// NewSync - concurrency-safe queue constructor
+// Accepts initial values to be enqueued, in the order listed
+func NewSync(initial ...interface{}) *SyncSqueue {
+	s := &SyncSqueue{sq: New(initial...)}
+	s.count()
+	return s
+}
+
+// Push - add to back of queue (enqueue)
+func (s *SyncSqueue) Push(elem interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sq.Push(elem)
+	s.count()
+	s.wake()
+}
+
+// Shift - add to front of queue
+func (s *SyncSqueue) Shift(elem interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sq.Shift(elem)
+	s.count()
+	s.wake()
+}
+
+// Pop - remove element from back of queue
+func (s *SyncSqueue) Pop() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.count()
+	return s.sq.Pop()
+}
+
+// PopWait - remove element from back of queue, waiting for one to be added if empty
+// Returns ctx.Err() if ctx is done before an element is available
+func (s *SyncSqueue) PopWait(ctx context.Context) (interface{}, error) {
+	for {
+		s.mu.Lock()
+		if elem, ok := s.sq.TryPop(); ok {
+			s.count()
+			s.mu.Unlock()
+			return elem, nil
+		}
+		if s.ready == nil {
+			s.ready = make(chan struct{})
+		}
+		ready := s.ready
+		s.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Unshift - remove element from front of queue (dequeue)
+func (s *SyncSqueue) Unshift() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.count()
+	return s.sq.Unshift()
+}
+
+// Clear - remove all elements from queue, retaining allocated memory for reuse
+func (s *SyncSqueue) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sq.Clear()
+	s.count()
+}
+
+// PeekFront - retrieve first element from queue without removing it
+func (s *SyncSqueue) PeekFront() (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sq.PeekFront()
+}
+
+// PeekBack - retrieve last element from queue without removing it
+func (s *SyncSqueue) PeekBack() (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sq.PeekBack()
+}
+
+// Size - returns number of elements in queue
+// Lock-free; reads the counter stored by the last add or delete
+func (s *SyncSqueue) Size() int {
+	return int(s.n.Load())
+}
+
+// Returns true if queue is empty
+// Lock-free, as Size
+func (s *SyncSqueue) Empty() bool {
+	return s.n.Load() == 0
+}
+
+/* Internals */
+
+// Stores the size of the queue for Size and Empty; requires the write lock
+func (s *SyncSqueue) count() {
+	s.n.Store(int64(s.sq.Size()))
+}
+
+// Wakes goroutines blocked in PopWait; requires the write lock
+func (s *SyncSqueue) wake() {
+	if s.ready != nil {
+		close(s.ready)
+		s.ready = nil
+	}
+}
@@ -0,0 +1,88 @@
+package squeue
+
+import (
+	"time"
+)
+
+// Timed queues
+//
+// TimedSqueue records when each element was pushed, for buffers that keep a
+// window of time rather than a count. Timestamps are held in an
+// SqueueOf[time.Time] parallel to the elements, so untimed queues pay nothing
+// for them and elements are stored as in any other Squeue.
+//
+// Elements are expected to be pushed in time order; EvictOlderThan only looks
+// at the front, stopping at the first element recent enough to keep.
+//
+// A TimedSqueue is not safe for concurrent use.
+
+/* Data Types */
+
+// TimedSqueue: queue of elements paired with their insertion times
+type TimedSqueue struct {
+	sq    Squeue              // Elements, oldest at the front
+	times SqueueOf[time.Time] // Insertion time of the element at the same position in sq
+}
+
+/* Exports */
+
+// NewTimed - timed queue constructor
+func NewTimed() *TimedSqueue {
+	return &TimedSqueue{sq: New(), times: NewOf[time.Time]()}
+}
+
+// PushTimed - add to back of queue, recording t as its insertion time
+// t should be no earlier than that of the element before it
+func (ts *TimedSqueue) PushTimed(elem interface{}, t time.Time) {
+	ts.sq.Push(elem)
+	ts.times.Push(t)
+}
+
+// Unshift - remove element from front of queue, along with its insertion time
+func (ts *TimedSqueue) Unshift() (interface{}, time.Time, error) {
+	t, err := ts.times.Unshift()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	elem, _ := ts.sq.Unshift()
+	return elem, t, nil
+}
+
+// PeekFront - retrieve first element from queue and its insertion time without removing it
+func (ts *TimedSqueue) PeekFront() (interface{}, time.Time, error) {
+	t, err := ts.times.PeekFront()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	elem, _ := ts.sq.PeekFront()
+	return elem, t, nil
+}
+
+// EvictOlderThan - remove elements from front of queue inserted before cutoff, returning the number removed
+// Stops at the first element inserted at or after cutoff
+func (ts *TimedSqueue) EvictOlderThan(cutoff time.Time) int {
+	n := 0
+	for {
+		t, err := ts.times.PeekFront()
+		if err != nil || !t.Before(cutoff) {
+			return n
+		}
+		ts.Unshift()
+		n++
+	}
+}
+
+// Size - returns number of elements in queue
+func (ts *TimedSqueue) Size() int {
+	return ts.sq.Size()
+}
+
+// Returns true if queue is empty
+func (ts *TimedSqueue) Empty() bool {
+	return ts.sq.Empty()
+}
+
+// Each - returns the elements, oldest first, without their times
+func (ts *TimedSqueue) Each() []interface{} {
+	return ts.sq.Each()
+}
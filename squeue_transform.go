@@ -0,0 +1,95 @@
+package squeue
+
+// Transformations
+//
+// Functions building new queues from the elements of existing ones. The
+// inputs are only read, never modified; the results share element values
+// with the inputs but none of their slices.
+
+// Filter - returns a new queue holding the elements for which pred returns true
+// Order is preserved
+func (sq *Squeue) Filter(pred func(interface{}) bool) Squeue {
+	res := New()
+	sq.walk(func(_ int, v interface{}) bool {
+		if pred(v) {
+			res.Push(v)
+		}
+		return true
+	})
+	return res
+}
+
+// Map - returns a new queue holding fn applied to each element
+// Order and size are preserved; fn may return nil, which is stored like any other value
+func (sq *Squeue) Map(fn func(interface{}) interface{}) Squeue {
+	s := make([]interface{}, sq.Size())
+	sq.walk(func(i int, v interface{}) bool {
+		s[i] = fn(v)
+		return true
+	})
+	return FromSlice(s)
+}
+
+// Partition - returns two new queues: the elements for which pred returns true, and the rest
+// Both preserve the order of the elements; the sizes sum to Size()
+func (sq *Squeue) Partition(pred func(interface{}) bool) (Squeue, Squeue) {
+	matched, unmatched := New(), New()
+	sq.walk(func(_ int, v interface{}) bool {
+		if pred(v) {
+			matched.Push(v)
+		} else {
+			unmatched.Push(v)
+		}
+		return true
+	})
+	return matched, unmatched
+}
+
+// Concat - returns a new queue holding the elements of each of qs in turn, front to back
+// Ex. Concat(&a, &b) gives the elements of a, then those of b; no arguments give an empty queue
+func Concat(qs ...*Squeue) Squeue {
+	n := 0
+	for _, q := range qs {
+		n += q.Size()
+	}
+	s, k := make([]interface{}, n), 0
+	for _, q := range qs {
+		k += q.CopyTo(s[k:])
+	}
+	return FromSlice(s)
+}
+
+// Interleave - returns a new queue alternating elements of a and b, starting with a
+// Once the shorter queue runs out, the rest of the longer one follows in order
+// Ex. Interleave of [1 2 3] and [x] gives [1 x 2 3]
+func Interleave(a, b *Squeue) Squeue {
+	sa, sb := a.ToSlice(), b.ToSlice()
+	s := make([]interface{}, 0, len(sa)+len(sb))
+	for i := 0; i < min(len(sa), len(sb)); i++ {
+		s = append(s, sa[i], sb[i])
+	}
+	s = append(s, sa[min(len(sa), len(sb)):]...)
+	s = append(s, sb[min(len(sa), len(sb)):]...)
+	return FromSlice(s)
+}
+
+// MergeSorted - returns a new sorted queue holding the elements of a and b, both sorted according to cmp
+// cmp is as for Search. Stable: of equal elements, those of a come first, each
+// queue's in their original order. The result is undefined if an input is not sorted
+func MergeSorted(a, b *Squeue, cmp func(x, y interface{}) int) Squeue {
+	sa, sb := a.ToSlice(), b.ToSlice()
+	s := make([]interface{}, 0, len(sa)+len(sb))
+	i, j := 0, 0
+	for i < len(sa) && j < len(sb) {
+		if cmp(sb[j], sa[i]) < 0 {
+			s = append(s, sb[j])
+			j++
+		} else {
+			s = append(s, sa[i])
+			i++
+		}
+	}
+	s = append(s, sa[i:]...)
+	s = append(s, sb[j:]...)
+	return FromSlice(s)
+}
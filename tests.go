@@ -1,11 +1,23 @@
 package squeue
 
 import (
+	"bytes"
+	"container/heap"
 	"container/list"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"math/rand"
+	"reflect"
 	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -84,6 +96,3098 @@ func CompareQueues(n ...int) {
 	fmt.Printf("SliceQueue runs on average in %.2f%% time and %.2fs%% memory of a LinkedQueue\n\n", meanRatT*100, meanRatM*100)
 }
 
+// Compare default squeue vs. pooled squeue
+// Pooling pays off when inner slices are discarded and reallocated across
+// queues, so the churn test runs the ladder workload on several fresh queues.
+// The push/pop test asks for a different slice length every round, which
+// the pool serves from a few power of two sizes
+func ComparePooled(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tD, mD := churnSQTest(func() Squeue { return New() })
+	fmt.Printf("SQ churn:        %vns, used ~%vKB\n", tD, mD/1000)
+	tP, mP := churnSQTest(func() Squeue { return NewPooled() })
+	fmt.Printf("SQ pooled churn: %vns, used ~%vKB\n", tP, mP/1000)
+	tD, mD = growPopSQTest(New())
+	fmt.Printf("SQ push/pop:        %vns, used ~%vKB\n", tD, mD/1000)
+	tP, mP = growPopSQTest(NewPooled())
+	fmt.Printf("SQ pooled push/pop: %vns, used ~%vKB\n\n", tP, mP/1000)
+}
+
+// Compare Each vs. AppendTo with a reused buffer
+// Reads the whole queue repeatedly; Each allocates a slice on every read
+func CompareAppendTo(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tE, mE := readSQTest(func(qq *Squeue, buf []interface{}) []interface{} { return qq.Each() })
+	fmt.Printf("SQ Each:     %vns, used ~%vKB\n", tE, mE/1000)
+	tA, mA := readSQTest(func(qq *Squeue, buf []interface{}) []interface{} { return qq.AppendTo(buf[:0]) })
+	fmt.Printf("SQ AppendTo: %vns, used ~%vKB\n\n", tA, mA/1000)
+}
+
+// Compare squeue of boxed ints vs. IntSqueue
+func CompareIntQueues(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tS, mS := pushPopSQTest()
+	fmt.Printf("SQ pushpop:    %vns, used ~%vKB\n", tS, mS/1000)
+	tI, mI := pushPopIntSQTest()
+	fmt.Printf("IntSQ pushpop: %vns, used ~%vKB\n", tI, mI/1000)
+	tS, mS = linearSQTest()
+	fmt.Printf("SQ linear:     %vns, used ~%vKB\n", tS, mS/1000)
+	tI, mI = linearIntSQTest()
+	fmt.Printf("IntSQ linear:  %vns, used ~%vKB\n\n", tI, mI/1000)
+}
+
+// Randomized check of squeue vs. a container/list deque
+// Applies m random Push/Shift/Pop/Unshift operations (scale by default) to
+// both, and compares size, front and back after every operation. The mix of
+// adds and deletes drifts over time, so the queue repeatedly grows across
+// many inner slices and shrinks again. The same seed gives the same run; the
+// error names the seed and operation of the first mismatch
+func FuzzSqueue(seed int64, m ...int) error {
+	n := scale
+	if len(m) > 0 {
+		n = m[0]
+	}
+	rng := rand.New(rand.NewSource(seed))
+	qq, ll := New(), list.New()
+
+	for op := 0; op < n; op++ {
+		// Probability of an add rises and falls between 1/4 and 3/4
+		grow := 0.5 + 0.25*math.Sin(float64(op)/500)
+		add, front := rng.Float64() < grow, rng.Intn(2) == 0
+		var got, want interface{}
+		var name string
+		switch {
+		case add && front:
+			name = "Shift"
+			qq.Shift(op)
+			ll.PushFront(op)
+		case add:
+			name = "Push"
+			qq.Push(op)
+			ll.PushBack(op)
+		case front:
+			name = "Unshift"
+			got, _ = qq.Unshift()
+			if e := ll.Front(); e != nil {
+				want = ll.Remove(e)
+			}
+		default:
+			name = "Pop"
+			got, _ = qq.Pop()
+			if e := ll.Back(); e != nil {
+				want = ll.Remove(e)
+			}
+		}
+		if got != want {
+			return fmt.Errorf("seed %d, op %d (%s): removed %v, want %v", seed, op, name, got, want)
+		}
+		if err := compareEnds(&qq, ll); err != nil {
+			return fmt.Errorf("seed %d, op %d (%s): %w", seed, op, name, err)
+		}
+	}
+	return nil
+}
+
+// Compare bursts of pushes with and without Grow
+// Each burst goes to a fresh queue; with Grow, the room for the burst is
+// allocated once up front instead of in doubling steps during the burst
+func CompareGrow(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tP, mP := burstSQTest(false)
+	fmt.Printf("SQ burst:      %vns, used ~%vKB\n", tP, mP/1000)
+	tG, mG := burstSQTest(true)
+	fmt.Printf("SQ Grow burst: %vns, used ~%vKB\n\n", tG, mG/1000)
+}
+
+// Compare peeks on empty and non-empty queues
+// Front and Back allocate nothing, whether or not the queue holds elements
+func ComparePeeks(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	qq := New()
+	tE, mE := peekSQTest(&qq)
+	fmt.Printf("SQ empty Front/Back: %vns, used %vB\n", tE, mE)
+	for i := 0; i < scale; i++ {
+		qq.Push(i)
+	}
+	tF, mF := peekSQTest(&qq)
+	fmt.Printf("SQ Front/Back:       %vns, used %vB\n\n", tF, mF)
+}
+
+// Compare refilling fresh queues vs. one cleared queue
+// Each cycle fills a queue and empties it. After warming up, the cleared
+// queue refills its retained slice, so its cycles should use 0B
+func CompareClear(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tN, mN := refillSQTest(false)
+	fmt.Printf("SQ New refill:   %vns, used %vB\n", tN, mN)
+	tC, mC := refillSQTest(true)
+	fmt.Printf("SQ Clear refill: %vns, used %vB\n\n", tC, mC)
+}
+
+// Compare TryUnshift/TryPop on empty and non-empty queues
+// Neither path allocates; on a non-empty queue, each remove follows a push
+// into a slot that is already allocated
+func CompareTryRemoves(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tE, mE := tryRemoveSQTest(false)
+	fmt.Printf("SQ empty TryUnshift/TryPop: %vns, used %vB\n", tE, mE)
+	tF, mF := tryRemoveSQTest(true)
+	fmt.Printf("SQ TryUnshift/TryPop:       %vns, used %vB\n\n", tF, mF)
+}
+
+// Compare adds and removes on a plain queue vs. one with a bound and hooks
+// The plain queue skips the bound, hook and compaction checks, so those
+// features cost it nothing. Neither allocates once warmed up
+func CompareHotPath(n ...int) {
+	if len(n) > 0 {
+		scale = n[0]
+	}
+	tP, mP := hotPathSQTest(New())
+	fmt.Printf("SQ plain adds/removes:  %vns, used %vB\n", tP, mP)
+	qq := NewBounded(2000)
+	qq.OnAdd(func(interface{}) {})
+	qq.OnRemove(func(interface{}) {})
+	tE, mE := hotPathSQTest(qq)
+	fmt.Printf("SQ hooked adds/removes: %vns, used %vB\n\n", tE, mE)
+}
+
+// Correctness checks
+//
+// Each CheckX function exercises one feature, mostly on queues spread over
+// several inner slices, and returns an error describing the first mismatch,
+// or nil. CheckAll runs them all and returns the first error.
+
+// Run every correctness check, returning the first error
+func CheckAll() error {
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks run by CheckAll, in order
+var checks = []func() error{
+	CheckJSON,
+	CheckSync,
+	CheckShrinkToFit,
+	CheckBounded,
+	CheckPeekFront,
+	CheckPeekBack,
+	CheckPeeksOf,
+	CheckErrEmpty,
+	CheckSwap,
+	CheckMerge,
+	CheckSplit,
+	CheckSort,
+	CheckDedup,
+	CheckMinMax,
+	CheckJoin,
+	CheckFormat,
+	CheckSnapshot,
+	CheckStats,
+	CheckSearch,
+	CheckInsertSorted,
+	CheckHeap,
+	CheckList,
+	CheckForEach,
+	CheckCopyTo,
+	CheckOptions,
+	CheckMaxInner,
+	CheckKeepLast,
+	CheckPopWait,
+	CheckDrainTo,
+	CheckFillFrom,
+	CheckByteSqueue,
+	CheckFold,
+	CheckPredicates,
+	CheckPartition,
+	CheckConcat,
+	CheckInterleave,
+	CheckDebugString,
+	CheckEach,
+	CheckResize,
+	CheckShuffle,
+	CheckSample,
+	CheckMust,
+	CheckPeekOr,
+	CheckWhile,
+	CheckHooks,
+	CheckMetrics,
+	CheckDeque,
+	CheckRestricted,
+	CheckBinary,
+	CheckText,
+	CheckCSV,
+	CheckStream,
+	CheckGoString,
+	CheckDiff,
+	CheckRotateToFront,
+	CheckIsSorted,
+	CheckMergeSorted,
+	CheckCompact,
+	CheckGrowthFactor,
+	CheckTrim,
+	CheckReplace,
+	CheckRemoveFunc,
+	CheckAutoCompact,
+	CheckFIFO,
+	CheckCollect,
+	CheckSyncSize,
+	CheckRemoveFirstLast,
+	CheckNewSized,
+	CheckWindow,
+	CheckTimed,
+	CheckOf,
+	CheckNil,
+	CheckAt,
+	CheckIterators,
+	CheckBackward,
+	CheckClone,
+	CheckToSlice,
+	CheckFromSlice,
+	CheckGob,
+	CheckCap,
+	CheckPushShiftAll,
+	CheckReverse,
+	CheckContains,
+	CheckIndexOf,
+	CheckRemoveAt,
+	CheckInsertAt,
+	CheckFilter,
+	CheckMap,
+	CheckEqual,
+	CheckDrain,
+	CheckRemoveN,
+	CheckGrow,
+	CheckPooledLengths,
+}
+
+// Check JSON round trips of nested elements, replacement of the receiver's contents, and null
+func CheckJSON() error {
+	qq := spread(100)
+	qq.Push(map[string]interface{}{"k": []interface{}{1.5, "v"}})
+	qq.Push(nil)
+	data, err := json.Marshal(&qq)
+	if err != nil {
+		return fmt.Errorf("JSON: %w", err)
+	}
+	// JSON numbers decode as float64
+	want := []interface{}{}
+	for i := 0; i < 100; i++ {
+		want = append(want, float64(i))
+	}
+	want = append(want, map[string]interface{}{"k": []interface{}{1.5, "v"}}, nil)
+	res := New("replaced")
+	if err := json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("JSON: %w", err)
+	}
+	if err := expect(&res, want...); err != nil {
+		return fmt.Errorf("JSON round trip: %w", err)
+	}
+	if err := json.Unmarshal([]byte("null"), &res); err != nil || res.Size() != len(want) {
+		return fmt.Errorf("JSON null: size %d, err %v; want receiver unchanged", res.Size(), err)
+	}
+	if err := json.Unmarshal([]byte("[]"), &res); err != nil || !res.Empty() {
+		return fmt.Errorf("JSON []: size %d, err %v; want empty", res.Size(), err)
+	}
+	return nil
+}
+
+// Check SyncSqueue under concurrent producers and consumers
+// Producers push and shift distinct elements while consumers pop and unshift
+// them; every element must be removed exactly once. Run under the race
+// detector (go test -race) to check the locking as well
+func CheckSync() error {
+	const producers, consumers = 4, 4
+	n := scale
+	total := int64(producers * n)
+	s := NewSync()
+	var removed atomic.Int64
+	got := make(chan []int, consumers)
+
+	for p := 0; p < producers; p++ {
+		go func(p int) {
+			for i := 0; i < n; i++ {
+				if i%2 == 0 {
+					s.Push(p*n + i)
+				} else {
+					s.Shift(p*n + i)
+				}
+			}
+		}(p)
+	}
+	for c := 0; c < consumers; c++ {
+		go func(c int) {
+			var mine []int
+			for removed.Load() < total {
+				remove := s.Unshift
+				if c%2 == 1 {
+					remove = s.Pop
+				}
+				if elem, err := remove(); err == nil {
+					mine = append(mine, elem.(int))
+					removed.Add(1)
+				} else {
+					runtime.Gosched()
+				}
+			}
+			got <- mine
+		}(c)
+	}
+
+	seen := make([]int, total)
+	for c := 0; c < consumers; c++ {
+		for _, elem := range <-got {
+			seen[elem]++
+		}
+	}
+	for elem, k := range seen {
+		if k != 1 {
+			return fmt.Errorf("SyncSqueue: element %d removed %d times, want 1", elem, k)
+		}
+	}
+	if !s.Empty() {
+		return fmt.Errorf("SyncSqueue: %d elements left, want 0", s.Size())
+	}
+	return nil
+}
+
+// Check ShrinkToFit on plain and pooled queues drained from a large size
+// Capacity drops to that of a new queue while the remaining elements are kept
+func CheckShrinkToFit() error {
+	for _, qq := range []Squeue{spread(100000), NewPooled()} {
+		if qq.Empty() {
+			qq.PushAll(span(0, 100000)...)
+		}
+		for i := 0; i < 99995; i++ {
+			qq.Unshift()
+		}
+		qq.ShrinkToFit()
+		if qq.Cap() != 20 {
+			return fmt.Errorf("ShrinkToFit: capacity %d, want 20", qq.Cap())
+		}
+		if err := expect(&qq, span(99995, 100000)...); err != nil {
+			return fmt.Errorf("ShrinkToFit: %w", err)
+		}
+	}
+	return nil
+}
+
+// Check bounded queues up to and past the bound
+// Adds to a full queue fail with ErrFull, and bulk adds that do not fit add
+// nothing, so no element is silently dropped
+func CheckBounded() error {
+	qq := NewBounded(50)
+	for i := 0; i < 50; i++ {
+		if err := qq.TryPush(i); err != nil {
+			return fmt.Errorf("Bounded: TryPush %d: %w", i, err)
+		}
+	}
+	if !qq.IsFull() || qq.TryPush(50) != ErrFull || qq.TryShift(-1) != ErrFull {
+		return fmt.Errorf("Bounded: adds to a full queue did not fail with ErrFull")
+	}
+	if err := mustPanic(ErrFull, func() { qq.Push(50) }); err != nil {
+		return fmt.Errorf("Bounded: Push: %w", err)
+	}
+	// Room for two more; three do not fit
+	qq.PopN(2)
+	three := New(48, 49, 50)
+	for name, add := range map[string]func(){
+		"PushAll":   func() { qq.PushAll(48, 49, 50) },
+		"ShiftAll":  func() { qq.ShiftAll(48, 49, 50) },
+		"MergeCopy": func() { qq.MergeCopy(&three) },
+	} {
+		if err := mustPanic(ErrFull, add); err != nil {
+			return fmt.Errorf("Bounded: %s: %w", name, err)
+		}
+		if err := expect(&qq, span(0, 48)...); err != nil {
+			return fmt.Errorf("Bounded: after %s: %w", name, err)
+		}
+	}
+	qq.PushAll(48, 49)
+	if err := expect(&qq, span(0, 50)...); err != nil {
+		return fmt.Errorf("Bounded: %w", err)
+	}
+	return nil
+}
+
+// Check that PeekFront leaves the queue untouched
+// Peeks 100 times in a row, including after the head has been emptied, and
+// compares the internal pointers and the value returned each time
+func CheckPeekFront() error {
+	qq := spread(1000)
+	// Empty the head slice, so the front is found in the next slice
+	for qq.headN > 0 {
+		qq.Unshift()
+	}
+	before, want := frozen(&qq), qq.ToSlice()[0]
+	for k := 0; k < 100; k++ {
+		if elem, err := qq.PeekFront(); elem != want || err != nil {
+			return fmt.Errorf("PeekFront: peek %d gave %v, %v; want %v", k, elem, err, want)
+		}
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check that PeekBack leaves the queue untouched
+// As CheckPeekFront, after the tail has been emptied
+func CheckPeekBack() error {
+	qq := spread(1000)
+	for qq.tailN > 0 {
+		qq.Pop()
+	}
+	s := qq.ToSlice()
+	before, want := frozen(&qq), s[len(s)-1]
+	for k := 0; k < 100; k++ {
+		if elem, err := qq.PeekBack(); elem != want || err != nil {
+			return fmt.Errorf("PeekBack: peek %d gave %v, %v; want %v", k, elem, err, want)
+		}
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check SqueueOf peeks against a container/list deque
+// Applies random adds and deletes as FuzzSqueue does, peeking at both ends
+// after each; the peeks must match the list and move no pointers
+func CheckPeeksOf() error {
+	rng := rand.New(rand.NewSource(1))
+	qq, ll := NewOf[int](), list.New()
+	for op := 0; op < scale; op++ {
+		grow := 0.5 + 0.25*math.Sin(float64(op)/500)
+		add, front := rng.Float64() < grow, rng.Intn(2) == 0
+		switch {
+		case add && front:
+			qq.Shift(op)
+			ll.PushFront(op)
+		case add:
+			qq.Push(op)
+			ll.PushBack(op)
+		case ll.Len() == 0:
+		case front:
+			qq.Unshift()
+			ll.Remove(ll.Front())
+		default:
+			qq.Pop()
+			ll.Remove(ll.Back())
+		}
+		before := qq
+		first, errF := qq.PeekFront()
+		last, errB := qq.PeekBack()
+		if qq.headF != before.headF || qq.headN != before.headN || qq.tailN != before.tailN ||
+			qq.cacheF != before.cacheF || qq.cacheL != before.cacheL || qq.cacheSize != before.cacheSize {
+			return fmt.Errorf("SqueueOf: op %d: peeks moved pointers", op)
+		}
+		if ll.Len() == 0 {
+			if errF != ErrEmpty || errB != ErrEmpty {
+				return fmt.Errorf("SqueueOf: op %d: peeks on empty queue gave %v, %v", op, errF, errB)
+			}
+			continue
+		}
+		if first != ll.Front().Value || last != ll.Back().Value || qq.Size() != ll.Len() {
+			return fmt.Errorf("SqueueOf: op %d: front %v, back %v, size %d; want %v, %v, %d",
+				op, first, last, qq.Size(), ll.Front().Value, ll.Back().Value, ll.Len())
+		}
+	}
+	return nil
+}
+
+// Check the errors returned by retrievals from an empty queue
+// Each matches ErrEmpty with errors.Is, keeps its message, and allocates
+// nothing, since the sentinel is returned as is
+func CheckErrEmpty() error {
+	if ErrEmpty.Error() != "squeue: queue is empty" {
+		return fmt.Errorf("ErrEmpty: message changed to %q", ErrEmpty.Error())
+	}
+	qq := spread(100)
+	qq.Clear()
+	retrievals := map[string]func() error{
+		"PeekFront": func() error { _, err := qq.PeekFront(); return err },
+		"PeekBack":  func() error { _, err := qq.PeekBack(); return err },
+		"Unshift":   func() error { _, err := qq.Unshift(); return err },
+		"Pop":       func() error { _, err := qq.Pop(); return err },
+	}
+	for name, retrieve := range retrievals {
+		if err := retrieve(); !errors.Is(err, ErrEmpty) {
+			return fmt.Errorf("ErrEmpty: %s returned %v", name, err)
+		}
+		runtime.ReadMemStats(&mem)
+		before := mem.Mallocs
+		for i := 0; i < scale; i++ {
+			retrieve()
+		}
+		runtime.ReadMemStats(&mem)
+		if allocs := mem.Mallocs - before; allocs > 0 {
+			return fmt.Errorf("ErrEmpty: %d calls to %s allocated %d times", scale, name, allocs)
+		}
+	}
+	return nil
+}
+
+// Check Swap within one inner slice, between the head and the tail, and out of range
+func CheckSwap() error {
+	qq := spread(200)
+	want := span(0, 200)
+	for _, ij := range [][2]int{{0, 1}, {0, 199}, {198, 2}, {50, 50}} {
+		i, j := ij[0], ij[1]
+		if err := qq.Swap(i, j); err != nil {
+			return fmt.Errorf("Swap(%d, %d): %w", i, j, err)
+		}
+		want[i], want[j] = want[j], want[i]
+		if err := expect(&qq, want...); err != nil {
+			return fmt.Errorf("Swap(%d, %d): %w", i, j, err)
+		}
+	}
+	if err := qq.Swap(0, 200); !errors.Is(err, ErrRange) {
+		return fmt.Errorf("Swap(0, 200): got %v, want ErrRange", err)
+	}
+	if err := qq.Swap(-1, 0); !errors.Is(err, ErrRange) {
+		return fmt.Errorf("Swap(-1, 0): got %v, want ErrRange", err)
+	}
+	return expect(&qq, want...)
+}
+
+// Check Merge and MergeCopy: order, draining vs. copying, merging into itself, and bounds
+func CheckMerge() error {
+	qq, other := spread(100), spread(300)
+	qq.MergeCopy(&other)
+	if err := expect(&qq, append(span(0, 100), span(0, 300)...)...); err != nil {
+		return fmt.Errorf("MergeCopy: %w", err)
+	}
+	if err := expect(&other, span(0, 300)...); err != nil {
+		return fmt.Errorf("MergeCopy: other changed: %w", err)
+	}
+	qq = spread(100)
+	qq.Merge(&other)
+	if err := expect(&qq, append(span(0, 100), span(0, 300)...)...); err != nil {
+		return fmt.Errorf("Merge: %w", err)
+	}
+	if err := expect(&other); err != nil {
+		return fmt.Errorf("Merge: other not drained: %w", err)
+	}
+	qq = spread(100)
+	qq.Merge(&qq)
+	if err := expect(&qq, append(span(0, 100), span(0, 100)...)...); err != nil {
+		return fmt.Errorf("Merge into itself: %w", err)
+	}
+	// A bounded queue without room for all of other refuses, moving nothing
+	dst, src := NewBounded(3, 1, 2), New(10, 11, 12)
+	if err := mustPanic(ErrFull, func() { dst.Merge(&src) }); err != nil {
+		return fmt.Errorf("Merge into full queue: %w", err)
+	}
+	if err := expect(&dst, 1, 2); err != nil {
+		return fmt.Errorf("Merge into full queue: %w", err)
+	}
+	if err := expect(&src, 10, 11, 12); err != nil {
+		return fmt.Errorf("Merge into full queue: other changed: %w", err)
+	}
+	// A ring keeps the newest elements
+	ring := NewRing(3)
+	ring.PushAll(1, 2)
+	ring.Merge(&src)
+	if err := expect(&ring, 10, 11, 12); err != nil {
+		return fmt.Errorf("Merge into ring: %w", err)
+	}
+	return nil
+}
+
+// Check Split at 0, at Size(), and in the middle across a cache boundary
+// Both halves keep their order, the returned half keeps the configuration,
+// and no hooks fire for the moved elements
+func CheckSplit() error {
+	for _, i := range []int{0, 1000, 333, 999} {
+		qq := spread(1000)
+		fired := 0
+		qq.OnAdd(func(interface{}) { fired++ })
+		qq.OnRemove(func(interface{}) { fired++ })
+		qq.bound = 2000
+		qq.setExtras()
+		res, err := qq.Split(i)
+		if err != nil {
+			return fmt.Errorf("Split(%d): %w", i, err)
+		}
+		if err := expect(&qq, span(0, i)...); err != nil {
+			return fmt.Errorf("Split(%d): kept: %w", i, err)
+		}
+		if err := expect(&res, span(i, 1000)...); err != nil {
+			return fmt.Errorf("Split(%d): returned: %w", i, err)
+		}
+		if fired != 0 {
+			return fmt.Errorf("Split(%d): hooks fired %d times, want 0", i, fired)
+		}
+		res.Push(1000)
+		if fired != 1 || res.bound != 2000 {
+			return fmt.Errorf("Split(%d): returned queue lost its hooks or bound", i)
+		}
+	}
+	qq := spread(10)
+	if _, err := qq.Split(11); !errors.Is(err, ErrRange) {
+		return fmt.Errorf("Split(11): got %v, want ErrRange", err)
+	}
+	return expect(&qq, span(0, 10)...)
+}
+
+// Check Sort on a shuffled queue spanning several inner slices, and adds and deletes afterward
+func CheckSort() error {
+	qq := spread(1000)
+	qq.Shuffle(rand.New(rand.NewSource(1)))
+	qq.Sort(func(a, b interface{}) bool { return a.(int) < b.(int) })
+	if err := expect(&qq, span(0, 1000)...); err != nil {
+		return fmt.Errorf("Sort: %w", err)
+	}
+	qq.Push(1000)
+	qq.Shift(-1)
+	if front, _ := qq.Unshift(); front != -1 {
+		return fmt.Errorf("Sort: Unshift gave %v after sorting, want -1", front)
+	}
+	if back, _ := qq.Pop(); back != 1000 {
+		return fmt.Errorf("Sort: Pop gave %v after sorting, want 1000", back)
+	}
+	return expect(&qq, span(0, 1000)...)
+}
+
+// Check Dedup and DedupAll with runs at both ends and across inner slices
+func CheckDedup() error {
+	eq := func(a, b interface{}) bool { return a == b }
+	// Runs of 7, so runs straddle the boundaries between inner slices
+	s := []interface{}{}
+	for i := 0; i < 1001; i++ {
+		s = append(s, i/7)
+	}
+	qq := spreadOf(s)
+	qq.Dedup(eq)
+	if err := expect(&qq, span(0, 143)...); err != nil {
+		return fmt.Errorf("Dedup: %w", err)
+	}
+	for i := range s {
+		s[i] = i % 13
+	}
+	qq = spreadOf(s)
+	qq.Dedup(eq)
+	if qq.Size() != len(s) {
+		return fmt.Errorf("Dedup: removed non-adjacent duplicates, size %d", qq.Size())
+	}
+	qq.DedupAll(eq)
+	if err := expect(&qq, span(0, 13)...); err != nil {
+		return fmt.Errorf("DedupAll: %w", err)
+	}
+	return nil
+}
+
+// Check Min and Max, including ties, which give the element nearest the front, and the empty queue
+func CheckMinMax() error {
+	// Elements are [value, position]; only values are compared
+	s := []interface{}{}
+	for i := 0; i < 500; i++ {
+		s = append(s, [2]int{i % 50, i})
+	}
+	qq := spreadOf(s)
+	less := func(a, b interface{}) bool { return a.([2]int)[0] < b.([2]int)[0] }
+	if elem, err := qq.Min(less); elem != [2]int{0, 0} || err != nil {
+		return fmt.Errorf("Min: got %v, %v; want [0 0]", elem, err)
+	}
+	if elem, err := qq.Max(less); elem != [2]int{49, 49} || err != nil {
+		return fmt.Errorf("Max: got %v, %v; want [49 49]", elem, err)
+	}
+	qq.Clear()
+	if _, err := qq.Min(less); err != ErrEmpty {
+		return fmt.Errorf("Min: empty queue gave %v, want ErrEmpty", err)
+	}
+	if _, err := qq.Max(less); err != ErrEmpty {
+		return fmt.Errorf("Max: empty queue gave %v, want ErrEmpty", err)
+	}
+	return nil
+}
+
+// Check String and Join: separators, per-element formatting, and the empty queue
+func CheckJoin() error {
+	qq, empty := New(1, "x", 2.5), New()
+	str := func(v interface{}) string { return fmt.Sprint(v) }
+	quote := func(v interface{}) string { return fmt.Sprintf("%q", str(v)) }
+	for _, c := range []struct{ got, want string }{
+		{qq.String(), "[1 x 2.5]"},
+		{qq.Join(", ", str), "1, x, 2.5"},
+		{qq.Join("", quote), `"1""x""2.5"`},
+		{empty.String(), "[]"},
+		{empty.Join(", ", str), ""},
+	} {
+		if c.got != c.want {
+			return fmt.Errorf("Join: got %q, want %q", c.got, c.want)
+		}
+	}
+	qq = spread(300)
+	if got, want := qq.Join(",", str), fmt.Sprint(span(0, 300)...); strings.ReplaceAll(got, ",", " ") != want {
+		return fmt.Errorf("Join: spread queue gave %q", got)
+	}
+	return nil
+}
+
+// Check Format under %v, %+v, %s, %d with width, %.1f and %#v, for queue values and pointers
+func CheckFormat() error {
+	qq := New(1, 2, 3)
+	for _, c := range []struct {
+		format string
+		arg    interface{}
+		want   string
+	}{
+		{"%v", qq, "[1 2 3]"},
+		{"%v", &qq, "[1 2 3]"},
+		{"%+v", qq, "[1 2 3]"},
+		{"%s", qq, "[1 2 3]"},
+		{"%3d", qq, "[  1   2   3]"},
+		{"%.1f", New(1.25, 2.5), "[1.2 2.5]"},
+		{"%#v", qq, "squeue.FromSlice([]interface {}{1, 2, 3})"},
+		{"%v", New(), "[]"},
+	} {
+		if got := fmt.Sprintf(c.format, c.arg); got != c.want {
+			return fmt.Errorf("Format: %s gave %q, want %q", c.format, got, c.want)
+		}
+	}
+	return nil
+}
+
+// Check Snapshot and Restore across heavy changes, and Restore beyond a bound
+func CheckSnapshot() error {
+	qq := spread(500)
+	snap := qq.Snapshot()
+	for i := 0; i < 2000; i++ {
+		qq.Push(i)
+		if i%3 == 0 {
+			qq.Unshift()
+		}
+	}
+	qq.Reverse()
+	if err := qq.Restore(snap); err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	small := NewBounded(100, 1, 2)
+	if err := small.Restore(snap); err != ErrFull {
+		return fmt.Errorf("Restore beyond bound: got %v, want ErrFull", err)
+	}
+	return expect(&small, 1, 2)
+}
+
+// Check Stats for known queue shapes: one slice, head and tail, and a cached slice between them
+func CheckStats() error {
+	qq := New(1, 2, 3)
+	if got, want := qq.Stats(), (Stats{Slices: 1, Capacity: 20, Size: 3, Fragmentation: 17}); got != want {
+		return fmt.Errorf("Stats: one slice: got %+v, want %+v", got, want)
+	}
+	// Fill the head, then push into a new tail of twice its length
+	qq.PushAll(span(3, 21)...)
+	if got, want := qq.Stats(), (Stats{Slices: 2, Capacity: 60, Size: 21, Fragmentation: 39}); got != want {
+		return fmt.Errorf("Stats: head and tail: got %+v, want %+v", got, want)
+	}
+	// The full head is cached, and a new head of twice the longest slice added
+	qq.Shift(0)
+	if got, want := qq.Stats(), (Stats{Slices: 3, Capacity: 140, Size: 22, Fragmentation: 118}); got != want {
+		return fmt.Errorf("Stats: cached slice: got %+v, want %+v", got, want)
+	}
+	return nil
+}
+
+// Check Search over a sorted queue spanning several inner slices
+// Targets found, before the front, after the back, and absent in the middle
+func CheckSearch() error {
+	// Even numbers 0, 2, ..., 1998
+	s := []interface{}{}
+	for i := 0; i < 1000; i++ {
+		s = append(s, 2*i)
+	}
+	qq := spreadOf(s)
+	for _, c := range []struct {
+		target, i int
+		found     bool
+	}{
+		{0, 0, true}, {1998, 999, true}, {600, 300, true},
+		{-5, 0, false}, {2001, 1000, false}, {601, 301, false},
+	} {
+		if i, found := qq.Search(c.target, cmpInt); i != c.i || found != c.found {
+			return fmt.Errorf("Search(%d): got %d, %v; want %d, %v", c.target, i, found, c.i, c.found)
+		}
+	}
+	return nil
+}
+
+// Check InsertSorted with a shuffled sequence inserted one at a time
+func CheckInsertSorted() error {
+	s := span(0, 1000)
+	rand.New(rand.NewSource(1)).Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+	qq := New()
+	for _, elem := range s {
+		if err := qq.InsertSorted(elem, cmpInt); err != nil {
+			return fmt.Errorf("InsertSorted(%v): %w", elem, err)
+		}
+	}
+	if err := expect(&qq, span(0, 1000)...); err != nil {
+		return fmt.Errorf("InsertSorted: %w", err)
+	}
+	return nil
+}
+
+// Check HeapAdapter: random ints through heap.Push come out of heap.Pop in sorted order
+func CheckHeap() error {
+	rng := rand.New(rand.NewSource(1))
+	qq := New()
+	h := NewHeapAdapter(&qq, func(a, b interface{}) bool { return a.(int) < b.(int) })
+	want := []int{}
+	for i := 0; i < 1000; i++ {
+		v := rng.Intn(500)
+		heap.Push(h, v)
+		want = append(want, v)
+	}
+	sort.Ints(want)
+	for i, v := range want {
+		if got := heap.Pop(h); got != v {
+			return fmt.Errorf("Heap: pop %d gave %v, want %d", i, got, v)
+		}
+	}
+	if !qq.Empty() {
+		return fmt.Errorf("Heap: %d elements left", qq.Size())
+	}
+	return nil
+}
+
+// Check ToList and FromList: FromList(ToList(q)) equals q
+func CheckList() error {
+	qq := spread(500)
+	l := ToList(&qq)
+	if l.Len() != 500 || l.Front().Value != 0 || l.Back().Value != 499 {
+		return fmt.Errorf("ToList: got %d elements, %v to %v", l.Len(), l.Front().Value, l.Back().Value)
+	}
+	res := FromList(l)
+	if !res.Equal(&qq) {
+		return fmt.Errorf("FromList(ToList(q)): got %v", res.ToSlice())
+	}
+	empty := New()
+	if res = FromList(ToList(&empty)); !res.Empty() {
+		return fmt.Errorf("FromList(ToList(q)): empty queue gave %v", res.ToSlice())
+	}
+	return nil
+}
+
+// Check ForEach and ForEachReverse on a queue spanning several slices
+// Full traversals see every element with its index; stopping early calls fn no further
+func CheckForEach() error {
+	qq := spread(500)
+	before := frozen(&qq)
+	next := 0
+	qq.ForEach(func(i int, v interface{}) bool {
+		if i == next && v == i {
+			next++
+		}
+		return true
+	})
+	if next != 500 {
+		return fmt.Errorf("ForEach: traversal broke off at index %d", next)
+	}
+	next = 499
+	qq.ForEachReverse(func(i int, v interface{}) bool {
+		if i == next && v == i {
+			next--
+		}
+		return true
+	})
+	if next != -1 {
+		return fmt.Errorf("ForEachReverse: traversal broke off at index %d", next)
+	}
+	calls := 0
+	qq.ForEach(func(i int, v interface{}) bool {
+		calls++
+		return i < 299
+	})
+	if calls != 300 {
+		return fmt.Errorf("ForEach: %d calls stopping at index 299, want 300", calls)
+	}
+	calls = 0
+	qq.ForEachReverse(func(i int, v interface{}) bool {
+		calls++
+		return i > 200
+	})
+	if calls != 300 {
+		return fmt.Errorf("ForEachReverse: %d calls stopping at index 200, want 300", calls)
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check CopyTo with buffers smaller than, as long as, and longer than the queue
+// Slots past the count copied must be left untouched
+func CheckCopyTo() error {
+	qq := spread(500)
+	for _, n := range []int{0, 1, 130, 500, 700} {
+		dst := make([]interface{}, n)
+		for i := range dst {
+			dst[i] = -1
+		}
+		got := qq.CopyTo(dst)
+		if got != min(n, 500) {
+			return fmt.Errorf("CopyTo: copied %d into %d slots", got, n)
+		}
+		for i, v := range dst {
+			if i < got && v != i || i >= got && v != -1 {
+				return fmt.Errorf("CopyTo: slot %d of %d holds %v", i, n, v)
+			}
+		}
+	}
+	return nil
+}
+
+// Check that each constructor option sets the initial sizes it names
+// Defaults match New(); the maximum inner length also caps the first slice
+func CheckOptions() error {
+	for _, c := range []struct {
+		opts       []Option
+		head, slot int
+	}{
+		{nil, 20, 6},
+		{[]Option{WithInitialCapacity(1024)}, 1024, 6},
+		{[]Option{WithCacheSize(16)}, 20, 16},
+		{[]Option{WithMaxInnerCap(8)}, 8, 6},
+		{[]Option{WithInitialCapacity(1024), WithMaxInnerCap(100)}, 100, 6},
+	} {
+		qq := NewWithOptions(c.opts...)
+		if qq.Cap() != c.head || len(qq.head) != c.head || len(qq.cache) != c.slot {
+			return fmt.Errorf("NewWithOptions: %d options gave head %d and %d cache slots, want %d and %d",
+				len(c.opts), len(qq.head), len(qq.cache), c.head, c.slot)
+		}
+	}
+	qq := NewWithOptions(WithMaxInnerCap(8))
+	if qq.maxInner != 8 {
+		return fmt.Errorf("WithMaxInnerCap: maximum set to %d", qq.maxInner)
+	}
+	return nil
+}
+
+// Check that a small maximum inner length holds as a queue grows at both ends
+// Every inner slice in use stays within the maximum, and the elements stay in order
+func CheckMaxInner() error {
+	qq := NewWithOptions(WithMaxInnerCap(16))
+	for i := 0; i < 2000; i++ {
+		qq.Push(i)
+		qq.Shift(-i - 1)
+		for k, c := 0, qq.cacheF; k < qq.liveSlots(); k, c = k+1, (c+1)%len(qq.cache) {
+			if n := len(*qq.cache[c].ptr); n > 16 {
+				return fmt.Errorf("WithMaxInnerCap: slice of length %d after %d pushes", n, i+1)
+			}
+		}
+	}
+	want := make([]interface{}, 0, 4000)
+	for i := -2000; i < 2000; i++ {
+		want = append(want, i)
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("WithMaxInnerCap: %w", err)
+	}
+	return nil
+}
+
+// Check KeepLast keeps the newest elements and voids the slots it empties
+// Across the inner slices still in use, only the kept elements remain referenced
+func CheckKeepLast() error {
+	for _, n := range []int{500, 301, 120, 1, 0, -3} {
+		qq := spread(500)
+		qq.KeepLast(n)
+		if err := expect(&qq, span(max(500-max(n, 0), 0), 500)...); err != nil {
+			return fmt.Errorf("KeepLast(%d): %w", n, err)
+		}
+		held := 0
+		for k, c := 0, qq.cacheF; k < qq.liveSlots(); k, c = k+1, (c+1)%len(qq.cache) {
+			for _, v := range *qq.cache[c].ptr {
+				if v != nil {
+					held++
+				}
+			}
+		}
+		if held != qq.Size() {
+			return fmt.Errorf("KeepLast(%d): %d slots still referenced, want %d", n, held, qq.Size())
+		}
+	}
+	return nil
+}
+
+// Check PopWait receives an element pushed while it blocks, and returns on cancellation
+func CheckPopWait() error {
+	s := NewSync()
+	type result struct {
+		elem interface{}
+		err  error
+	}
+	got := make(chan result, 1)
+	go func() {
+		elem, err := s.PopWait(context.Background())
+		got <- result{elem, err}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	s.Push(42)
+	select {
+	case r := <-got:
+		if r.elem != 42 || r.err != nil {
+			return fmt.Errorf("PopWait: got %v, %v; want 42", r.elem, r.err)
+		}
+	case <-time.After(time.Second):
+		return fmt.Errorf("PopWait: not woken by a push")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if elem, err := s.PopWait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("PopWait: got %v, %v on an empty queue; want context.DeadlineExceeded", elem, err)
+	}
+	if !s.Empty() {
+		return fmt.Errorf("PopWait: %d elements left, want 0", s.Size())
+	}
+	return nil
+}
+
+// Check DrainTo sends every element, front to back, into a buffered channel
+// The queue is left empty and the channel open
+func CheckDrainTo() error {
+	qq := spread(500)
+	ch := make(chan interface{}, 500)
+	qq.DrainTo(ch)
+	if !qq.Empty() {
+		return fmt.Errorf("DrainTo: %d elements left, want 0", qq.Size())
+	}
+	for i := 0; i < 500; i++ {
+		if v := <-ch; v != i {
+			return fmt.Errorf("DrainTo: received %v at %d", v, i)
+		}
+	}
+	select {
+	case v, ok := <-ch:
+		return fmt.Errorf("DrainTo: extra receive %v, %v", v, ok)
+	default:
+	}
+	return nil
+}
+
+// Check FillFrom pushes every value from a closed channel, in the order sent
+func CheckFillFrom() error {
+	ch := make(chan interface{}, 1000)
+	for i := 0; i < 1000; i++ {
+		ch <- i
+	}
+	close(ch)
+	qq := New(-1)
+	qq.FillFrom(ch)
+	if err := expect(&qq, append([]interface{}{-1}, span(0, 1000)...)...); err != nil {
+		return fmt.Errorf("FillFrom: %w", err)
+	}
+	return nil
+}
+
+// Check a ByteSqueue round trip of more bytes than one inner slice holds
+// Reads return 0, nil while the writer is open, then io.EOF once it is closed
+func CheckByteSqueue() error {
+	data := make([]byte, 250000)
+	rand.New(rand.NewSource(1)).Read(data)
+	b := NewByte()
+	for i := 0; i < len(data); i += 7000 {
+		if n, err := b.Write(data[i:min(i+7000, len(data))]); err != nil || n != min(7000, len(data)-i) {
+			return fmt.Errorf("ByteSqueue: write at %d gave %d, %v", i, n, err)
+		}
+	}
+	got, p := make([]byte, 0, len(data)), make([]byte, 4096)
+	for {
+		n, err := b.Read(p)
+		got = append(got, p[:n]...)
+		if n == 0 && err == nil {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ByteSqueue: read before close: %w", err)
+		}
+	}
+	if !bytes.Equal(got, data) {
+		return fmt.Errorf("ByteSqueue: read back %d bytes, differing from the %d written", len(got), len(data))
+	}
+	b.Write(data)
+	b.CloseWrite()
+	if got, err := io.ReadAll(b); err != nil || !bytes.Equal(got, data) {
+		return fmt.Errorf("ByteSqueue: read %d bytes after close, %v", len(got), err)
+	}
+	if _, err := b.Write(data); !errors.Is(err, io.ErrClosedPipe) {
+		return fmt.Errorf("ByteSqueue: write after close gave %v, want io.ErrClosedPipe", err)
+	}
+	return nil
+}
+
+// Check Fold sums ints and concatenates strings front to back, and returns init on an empty queue
+func CheckFold() error {
+	qq := spread(500)
+	if got := qq.Fold(0, func(acc, v interface{}) interface{} { return acc.(int) + v.(int) }); got != 500*499/2 {
+		return fmt.Errorf("Fold: sum gave %v, want %d", got, 500*499/2)
+	}
+	words := New("a", "b", "c")
+	if got := words.Fold(">", func(acc, v interface{}) interface{} { return acc.(string) + v.(string) }); got != ">abc" {
+		return fmt.Errorf("Fold: concatenation gave %v, want >abc", got)
+	}
+	empty := New()
+	if got := empty.Fold("init", func(acc, v interface{}) interface{} { return nil }); got != "init" {
+		return fmt.Errorf("Fold: empty queue gave %v, want init", got)
+	}
+	return expect(&qq, span(0, 500)...)
+}
+
+// Check Count, Any and Every over several predicates, and on an empty queue
+// Any and Every stop at the first element deciding the result
+func CheckPredicates() error {
+	qq := spread(500)
+	even := func(v interface{}) bool { return v.(int)%2 == 0 }
+	small := func(v interface{}) bool { return v.(int) < 500 }
+	if n := qq.Count(even); n != 250 {
+		return fmt.Errorf("Count: %d even elements, want 250", n)
+	}
+	if n := qq.Count(small); n != 500 {
+		return fmt.Errorf("Count: %d elements below 500, want 500", n)
+	}
+	if !qq.Any(even) || qq.Any(func(v interface{}) bool { return v.(int) >= 500 }) {
+		return fmt.Errorf("Any: wrong result")
+	}
+	if qq.Every(even) || !qq.Every(small) {
+		return fmt.Errorf("Every: wrong result")
+	}
+	calls := 0
+	qq.Any(func(v interface{}) bool { calls++; return v == 10 })
+	if calls != 11 {
+		return fmt.Errorf("Any: %d calls for a match at index 10, want 11", calls)
+	}
+	calls = 0
+	qq.Every(func(v interface{}) bool { calls++; return v != 10 })
+	if calls != 11 {
+		return fmt.Errorf("Every: %d calls for a failure at index 10, want 11", calls)
+	}
+	empty := New()
+	if empty.Count(even) != 0 || empty.Any(small) || !empty.Every(even) {
+		return fmt.Errorf("Count/Any/Every: empty queue gave %d, %v, %v; want 0, false, true",
+			empty.Count(even), empty.Any(small), empty.Every(even))
+	}
+	return nil
+}
+
+// Check Partition splits by a predicate, in order, leaving the receiver intact
+func CheckPartition() error {
+	qq := spread(500)
+	in, out := qq.Partition(func(v interface{}) bool { return v.(int)%3 == 0 })
+	var want, rest []interface{}
+	for i := 0; i < 500; i++ {
+		if i%3 == 0 {
+			want = append(want, i)
+		} else {
+			rest = append(rest, i)
+		}
+	}
+	if err := expect(&in, want...); err != nil {
+		return fmt.Errorf("Partition: matched: %w", err)
+	}
+	if err := expect(&out, rest...); err != nil {
+		return fmt.Errorf("Partition: unmatched: %w", err)
+	}
+	if in.Size()+out.Size() != qq.Size() {
+		return fmt.Errorf("Partition: sizes %d and %d, want sum %d", in.Size(), out.Size(), qq.Size())
+	}
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("Partition: receiver: %w", err)
+	}
+	return nil
+}
+
+// Check Concat of no queues, one queue, and several including empty ones
+// The inputs are left untouched
+func CheckConcat() error {
+	if res := Concat(); !res.Empty() {
+		return fmt.Errorf("Concat(): got %v", res.ToSlice())
+	}
+	a, b, c, empty := spread(200), New(200, 201), spread(0), New()
+	res := Concat(&a)
+	if err := expect(&res, span(0, 200)...); err != nil {
+		return fmt.Errorf("Concat(a): %w", err)
+	}
+	res = Concat(&empty, &a, &c, &b, &empty)
+	if err := expect(&res, span(0, 202)...); err != nil {
+		return fmt.Errorf("Concat: %w", err)
+	}
+	if err := expect(&a, span(0, 200)...); err != nil {
+		return fmt.Errorf("Concat: input: %w", err)
+	}
+	return expect(&b, 200, 201)
+}
+
+// Check Interleave of equal and unequal lengths, and with an empty queue
+// The inputs are left untouched
+func CheckInterleave() error {
+	a, b, c, empty := New(1, 2, 3), New("x", "y", "z"), New("x"), New()
+	for _, tc := range []struct {
+		a, b *Squeue
+		want []interface{}
+	}{
+		{&a, &b, []interface{}{1, "x", 2, "y", 3, "z"}},
+		{&a, &c, []interface{}{1, "x", 2, 3}},
+		{&c, &a, []interface{}{"x", 1, 2, 3}},
+		{&a, &empty, []interface{}{1, 2, 3}},
+		{&empty, &b, []interface{}{"x", "y", "z"}},
+		{&empty, &empty, nil},
+	} {
+		res := Interleave(tc.a, tc.b)
+		if err := expect(&res, tc.want...); err != nil {
+			return fmt.Errorf("Interleave: %w", err)
+		}
+	}
+	return expect(&a, 1, 2, 3)
+}
+
+// Check DebugString shows the pointers, slices and cache entries after a known sequence
+// A 4-slot head is filled and the pushes overflow into an 8-slot tail
+func CheckDebugString() error {
+	qq := NewWithOptions(WithInitialCapacity(4))
+	for i := 0; i < 6; i++ {
+		qq.Push(i)
+	}
+	got := qq.DebugString()
+	for _, want := range []string{
+		"size=6 cacheF=0 cacheL=2 cacheSize=0\n",
+		"head: F=0 L=0 N=4 [0 1 2 3]\n",
+		"tail: F=0 L=2 N=2 [4 5 <nil> <nil> <nil> <nil> <nil> <nil>]\n",
+		"cache[0]: idx=0 len=4 (head)\n",
+		"cache[1]: idx=0 len=8 (tail)\n",
+		"cache[5]: nil\n",
+	} {
+		if !strings.Contains(got, want) {
+			return fmt.Errorf("DebugString: missing %q in\n%s", want, got)
+		}
+	}
+	return nil
+}
+
+// Check Each returns every element exactly once with 3 or more interior cached slices
+// Shifts wrap the head slot around the cache, so both the plain and wrapped scans are covered
+func CheckEach() error {
+	qq := NewWithOptions(WithInitialCapacity(2), WithMaxInnerCap(4))
+	for i := 0; i < 60; i++ {
+		qq.Push(i)
+		if got := qq.Each(); !reflect.DeepEqual(got, span(0, i+1)) {
+			return fmt.Errorf("Each: got %v after %d pushes", got, i+1)
+		}
+	}
+	for i := -1; i >= -60; i-- {
+		qq.Shift(i)
+	}
+	if k := qq.liveSlots() - 2; k < 3 {
+		return fmt.Errorf("Each: %d interior slices, want 3 or more", k)
+	}
+	if qq.cacheF < qq.cacheL {
+		return fmt.Errorf("Each: head slot %d before tail slot %d; want the cache wrapped", qq.cacheF, qq.cacheL-1)
+	}
+	seen := map[interface{}]int{}
+	for _, v := range qq.Each() {
+		seen[v]++
+	}
+	for i := -60; i < 60; i++ {
+		if seen[i] != 1 {
+			return fmt.Errorf("Each: element %d returned %d times, want 1", i, seen[i])
+		}
+	}
+	if len(seen) != 120 {
+		return fmt.Errorf("Each: %d distinct elements, want 120", len(seen))
+	}
+	return nil
+}
+
+// Check cache growth at exact capacity boundaries, for Squeue and SqueueOf
+// Whenever every cache slot is in use, resizing to fewer slots than that, or
+// to exactly that many, must keep every slice; so must the growth that follows
+func CheckResize() error {
+	qq := NewWithOptions(WithInitialCapacity(2), WithMaxInnerCap(4))
+	gq := NewOf[int]()
+	want := []interface{}{}
+	for i := 0; i < 3000; i++ {
+		if i%3 == 0 {
+			qq.Shift(i)
+			gq.Shift(i)
+			want = append([]interface{}{i}, want...)
+		} else {
+			qq.Push(i)
+			gq.Push(i)
+			want = append(want, i)
+		}
+		if qq.tail != nil && qq.cacheF == qq.cacheL {
+			for _, m := range []int{1, len(qq.cache)} {
+				c := qq
+				c.resize(m)
+				if err := expect(&c, want...); err != nil {
+					return fmt.Errorf("resize(%d) of a full %d-slot cache: %w", m, len(qq.cache), err)
+				}
+			}
+		}
+		if gq.tail != nil && gq.cacheF == gq.cacheL {
+			for _, m := range []int{1, len(gq.cache)} {
+				c := gq
+				c.resize(m)
+				if got := c.Each(); len(got) != len(want) || fmt.Sprint(got) != fmt.Sprint(want) {
+					return fmt.Errorf("SqueueOf resize(%d) of a full %d-slot cache: got %d elements, want %d", m, len(gq.cache), len(got), len(want))
+				}
+			}
+		}
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("resize: %w", err)
+	}
+	if got := gq.Each(); fmt.Sprint(got) != fmt.Sprint(want) {
+		return fmt.Errorf("SqueueOf resize: got %v", got)
+	}
+	return nil
+}
+
+// Check Shuffle under a fixed seed gives a known permutation and keeps every element
+// Across several slices, the result matches Fisher–Yates on a plain slice with the same seed
+func CheckShuffle() error {
+	qq := New(span(0, 10)...)
+	qq.Shuffle(rand.New(rand.NewSource(1)))
+	if err := expect(&qq, 4, 8, 2, 5, 3, 9, 0, 7, 6, 1); err != nil {
+		return fmt.Errorf("Shuffle: %w", err)
+	}
+	qq = spread(500)
+	qq.Shuffle(rand.New(rand.NewSource(2)))
+	want, rng := span(0, 500), rand.New(rand.NewSource(2))
+	for i := len(want) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		want[i], want[j] = want[j], want[i]
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("Shuffle: %w", err)
+	}
+	got := []int{}
+	for _, v := range qq.ToSlice() {
+		got = append(got, v.(int))
+	}
+	sort.Ints(got)
+	for i, v := range got {
+		if v != i {
+			return fmt.Errorf("Shuffle: sorted elements differ at %d: %d", i, v)
+		}
+	}
+	return nil
+}
+
+// Check Sample and SampleN repeat under the same seed and draw only elements in the queue
+// SampleN draws distinct elements, and rejects counts out of range; Sample errors when empty
+func CheckSample() error {
+	qq := spread(500)
+	for seed := int64(0); seed < 20; seed++ {
+		a, err := qq.Sample(rand.New(rand.NewSource(seed)))
+		b, _ := qq.Sample(rand.New(rand.NewSource(seed)))
+		if err != nil || a != b || a.(int) < 0 || a.(int) >= 500 {
+			return fmt.Errorf("Sample: seed %d gave %v and %v, %v", seed, a, b, err)
+		}
+		s, err := qq.SampleN(50, rand.New(rand.NewSource(seed)))
+		t, _ := qq.SampleN(50, rand.New(rand.NewSource(seed)))
+		if err != nil || len(s) != 50 || !reflect.DeepEqual(s, t) {
+			return fmt.Errorf("SampleN: seed %d gave %v and %v, %v", seed, s, t, err)
+		}
+		seen := map[interface{}]bool{}
+		for _, v := range s {
+			if seen[v] || v.(int) < 0 || v.(int) >= 500 {
+				return fmt.Errorf("SampleN: seed %d drew %v twice or out of range", seed, v)
+			}
+			seen[v] = true
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	if all, err := qq.SampleN(500, rng); err != nil || len(all) != 500 {
+		return fmt.Errorf("SampleN(500): got %d elements, %v", len(all), err)
+	}
+	if _, err := qq.SampleN(501, rng); err == nil {
+		return fmt.Errorf("SampleN(501): no error")
+	}
+	if _, err := qq.SampleN(-1, rng); err == nil {
+		return fmt.Errorf("SampleN(-1): no error")
+	}
+	empty := New()
+	if _, err := empty.Sample(rng); !errors.Is(err, ErrEmpty) {
+		return fmt.Errorf("Sample: empty queue gave %v, want ErrEmpty", err)
+	}
+	return expect(&qq, span(0, 500)...)
+}
+
+// Check MustUnshift and MustPop return the end elements, and panic with ErrEmpty once empty
+func CheckMust() error {
+	qq := spread(200)
+	for i := 0; i < 100; i++ {
+		if v := qq.MustUnshift(); v != i {
+			return fmt.Errorf("MustUnshift: got %v, want %d", v, i)
+		}
+		if v := qq.MustPop(); v != 199-i {
+			return fmt.Errorf("MustPop: got %v, want %d", v, 199-i)
+		}
+	}
+	if err := mustPanic(ErrEmpty, func() { qq.MustPop() }); err != nil {
+		return fmt.Errorf("MustPop: %w", err)
+	}
+	if err := mustPanic(ErrEmpty, func() { qq.MustUnshift() }); err != nil {
+		return fmt.Errorf("MustUnshift: %w", err)
+	}
+	return nil
+}
+
+// Check PeekFrontOr and PeekBackOr return the default only when empty, leaving the queue untouched
+func CheckPeekOr() error {
+	qq := spread(1000)
+	for qq.headN > 0 {
+		qq.Unshift()
+	}
+	for qq.tailN > 0 {
+		qq.Pop()
+	}
+	s := qq.ToSlice()
+	before := frozen(&qq)
+	if v := qq.PeekFrontOr("def"); v != s[0] {
+		return fmt.Errorf("PeekFrontOr: got %v, want %v", v, s[0])
+	}
+	if v := qq.PeekBackOr("def"); v != s[len(s)-1] {
+		return fmt.Errorf("PeekBackOr: got %v, want %v", v, s[len(s)-1])
+	}
+	if err := samePointers(&qq, &before); err != nil {
+		return err
+	}
+	empty := New()
+	if v := empty.PeekFrontOr("def"); v != "def" {
+		return fmt.Errorf("PeekFrontOr: empty queue gave %v, want def", v)
+	}
+	if v := empty.PeekBackOr(nil); v != nil {
+		return fmt.Errorf("PeekBackOr: empty queue gave %v, want nil", v)
+	}
+	return nil
+}
+
+// Check UnshiftWhile and PopWhile when no, some, and all elements match
+// The first element failing the predicate stays in the queue
+func CheckWhile() error {
+	below := func(n int) func(interface{}) bool {
+		return func(v interface{}) bool { return v.(int) < n }
+	}
+	above := func(n int) func(interface{}) bool {
+		return func(v interface{}) bool { return v.(int) > n }
+	}
+	qq := spread(500)
+	if got := qq.UnshiftWhile(below(0)); len(got) != 0 || qq.Size() != 500 {
+		return fmt.Errorf("UnshiftWhile: none matching removed %v", got)
+	}
+	if got := qq.PopWhile(above(499)); len(got) != 0 || qq.Size() != 500 {
+		return fmt.Errorf("PopWhile: none matching removed %v", got)
+	}
+	if got := qq.UnshiftWhile(below(150)); !reflect.DeepEqual(got, span(0, 150)) {
+		return fmt.Errorf("UnshiftWhile: some matching removed %v", got)
+	}
+	want := span(350, 500)
+	for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+		want[i], want[j] = want[j], want[i]
+	}
+	if got := qq.PopWhile(above(349)); !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("PopWhile: some matching removed %v", got)
+	}
+	if err := expect(&qq, span(150, 350)...); err != nil {
+		return fmt.Errorf("UnshiftWhile/PopWhile: %w", err)
+	}
+	if got := qq.UnshiftWhile(below(500)); !reflect.DeepEqual(got, span(150, 350)) || !qq.Empty() {
+		return fmt.Errorf("UnshiftWhile: all matching removed %v", got)
+	}
+	qq = spread(10)
+	if got := qq.PopWhile(below(500)); len(got) != 10 || got[0] != 9 || !qq.Empty() {
+		return fmt.Errorf("PopWhile: all matching removed %v", got)
+	}
+	return nil
+}
+
+// Check OnAdd and OnRemove fire with each element added or removed, in order
+// A ring reports each eviction before the add that caused it
+func CheckHooks() error {
+	var events []string
+	record := func(qq *Squeue) {
+		qq.OnAdd(func(v interface{}) { events = append(events, fmt.Sprint("+", v)) })
+		qq.OnRemove(func(v interface{}) { events = append(events, fmt.Sprint("-", v)) })
+	}
+	qq := New()
+	record(&qq)
+	qq.Push(1)
+	qq.Shift(0)
+	qq.Push(2)
+	qq.Pop()
+	qq.Unshift()
+	qq.TryPop()
+	qq.TryPop()
+	if want := []string{"+1", "+0", "+2", "-2", "-0", "-1"}; !reflect.DeepEqual(events, want) {
+		return fmt.Errorf("hooks: got %v, want %v", events, want)
+	}
+	events = nil
+	ring := NewRing(2)
+	record(&ring)
+	ring.Push(1)
+	ring.Push(2)
+	ring.Push(3)
+	ring.Shift(0)
+	if want := []string{"+1", "+2", "-1", "+3", "-3", "+0"}; !reflect.DeepEqual(events, want) {
+		return fmt.Errorf("hooks: ring got %v, want %v", events, want)
+	}
+	events = nil
+	qq.OnAdd(nil)
+	qq.OnRemove(nil)
+	qq.Push(1)
+	qq.Pop()
+	if len(events) != 0 {
+		return fmt.Errorf("hooks: removed hooks still fired %v", events)
+	}
+	return nil
+}
+
+// Check Metrics counts a known sequence of operations, and ResetMetrics zeroes them
+// Grows is checked against the cache reallocations actually seen
+func CheckMetrics() error {
+	qq := NewWithOptions(WithInitialCapacity(2), WithMaxInnerCap(4), WithCacheSize(2))
+	grows, slots := 0, len(qq.cache)
+	seen := func() {
+		if len(qq.cache) != slots {
+			grows, slots = grows+1, len(qq.cache)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		qq.Push(i)
+		seen()
+	}
+	for i := 0; i < 30; i++ {
+		qq.Shift(i)
+		seen()
+	}
+	for i := 0; i < 20; i++ {
+		qq.Pop()
+	}
+	for i := 0; i < 10; i++ {
+		qq.Unshift()
+	}
+	qq.TryPop()
+	qq.TryUnshift()
+	qq.PushAll(1, 2, 3)
+	seen()
+	want := Metrics{Pushes: 103, Shifts: 30, Pops: 21, Unshifts: 11, Grows: grows}
+	if got := qq.Metrics(); got != want || grows == 0 {
+		return fmt.Errorf("Metrics: got %+v, want %+v", got, want)
+	}
+	qq.ResetMetrics()
+	if got := qq.Metrics(); got != (Metrics{}) {
+		return fmt.Errorf("ResetMetrics: got %+v", got)
+	}
+	return nil
+}
+
+// Check each Deque implementation through the interface: plain, bounded, ring and sync queues
+func CheckDeque() error {
+	plain, bounded, ring := New(), NewBounded(100), NewRing(100)
+	for _, d := range []Deque{&plain, &bounded, &ring, NewSync()} {
+		if !d.Empty() {
+			return fmt.Errorf("Deque %T: new queue holds %d elements", d, d.Size())
+		}
+		for i := 0; i < 50; i++ {
+			d.Push(i)
+			d.Shift(-i - 1)
+		}
+		if d.Size() != 100 {
+			return fmt.Errorf("Deque %T: size %d, want 100", d, d.Size())
+		}
+		first, err1 := d.PeekFront()
+		last, err2 := d.PeekBack()
+		if first != -50 || last != 49 || err1 != nil || err2 != nil {
+			return fmt.Errorf("Deque %T: peeks gave %v, %v", d, first, last)
+		}
+		for i := 0; i < 50; i++ {
+			front, _ := d.Unshift()
+			back, _ := d.Pop()
+			if front != i-50 || back != 49-i {
+				return fmt.Errorf("Deque %T: removal %d gave %v, %v", d, i, front, back)
+			}
+		}
+		if _, err := d.Pop(); !d.Empty() || !errors.Is(err, ErrEmpty) {
+			return fmt.Errorf("Deque %T: pop from empty queue gave %v", d, err)
+		}
+	}
+	return nil
+}
+
+// Check Queue removes in insertion order and Stack in reverse, each peeking what it removes next
+func CheckRestricted() error {
+	q, s := NewQueue(0, 1), NewStack(0, 1)
+	for i := 2; i < 300; i++ {
+		q.Push(i)
+		s.Push(i)
+	}
+	for i := 0; i < 300; i++ {
+		if v, err := q.Peek(); v != i || err != nil {
+			return fmt.Errorf("Queue: peek %d gave %v, %v", i, v, err)
+		}
+		if v, err := q.Unshift(); v != i || err != nil {
+			return fmt.Errorf("Queue: dequeue %d gave %v, %v", i, v, err)
+		}
+		if v, err := s.Peek(); v != 299-i || err != nil {
+			return fmt.Errorf("Stack: peek %d gave %v, %v", i, v, err)
+		}
+		if v, err := s.Pop(); v != 299-i || err != nil {
+			return fmt.Errorf("Stack: pop %d gave %v, %v", i, v, err)
+		}
+		if q.Size() != 299-i || s.Size() != 299-i {
+			return fmt.Errorf("Queue/Stack: sizes %d, %d after %d removals", q.Size(), s.Size(), i+1)
+		}
+	}
+	if _, err := q.Unshift(); !q.Empty() || !errors.Is(err, ErrEmpty) {
+		return fmt.Errorf("Queue: dequeue from empty queue gave %v", err)
+	}
+	if _, err := s.Peek(); !s.Empty() || !errors.Is(err, ErrEmpty) {
+		return fmt.Errorf("Stack: peek at empty stack gave %v", err)
+	}
+	return nil
+}
+
+// Check a MarshalBinary round trip keeps the elements in order, replacing the receiver's
+func CheckBinary() error {
+	qq := spread(500)
+	qq.Push("end")
+	data, err := qq.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("MarshalBinary: %w", err)
+	}
+	res := New("replaced")
+	if err := res.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("UnmarshalBinary: %w", err)
+	}
+	if !reflect.DeepEqual(res.Each(), qq.Each()) {
+		return fmt.Errorf("Binary round trip: got %v", res.Each())
+	}
+	empty := New()
+	if data, err = empty.MarshalBinary(); err == nil {
+		err = res.UnmarshalBinary(data)
+	}
+	if err != nil || !res.Empty() {
+		return fmt.Errorf("Binary round trip: empty queue gave %v, %v", res.Each(), err)
+	}
+	return nil
+}
+
+// Check a MarshalText round trip of string queues, including empty strings
+// Text that would not decode to the same queue must be rejected
+func CheckText() error {
+	for _, want := range [][]interface{}{
+		{"a", "", "b c", ""},
+		{"", ""},
+		{"only"},
+		nil,
+	} {
+		qq := New(want...)
+		text, err := qq.MarshalText()
+		if err != nil {
+			return fmt.Errorf("MarshalText: %q: %w", want, err)
+		}
+		res := New("replaced")
+		if err := res.UnmarshalText(text); err != nil {
+			return fmt.Errorf("UnmarshalText: %q: %w", text, err)
+		}
+		if err := expect(&res, want...); err != nil {
+			return fmt.Errorf("Text round trip: %w", err)
+		}
+	}
+	for _, bad := range []Squeue{New(""), New("a", 1), New("a\nb")} {
+		if text, err := bad.MarshalText(); err == nil {
+			return fmt.Errorf("MarshalText: %v gave %q, want an error", bad.ToSlice(), text)
+		}
+	}
+	return nil
+}
+
+// Check a CSV round trip of several records through a buffer, including empty fields
+// Non-string elements come back as their fmt.Sprint text
+func CheckCSV() error {
+	rows := []Squeue{New("a", "", "b,c", "say \"hi\"", ""), New(1, 2.5, nil), New("", "")}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for i := range rows {
+		if err := rows[i].WriteCSVRow(w); err != nil {
+			return fmt.Errorf("WriteCSVRow: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("WriteCSVRow: %w", err)
+	}
+	r := csv.NewReader(&buf)
+	r.FieldsPerRecord = -1
+	for _, want := range [][]interface{}{
+		{"a", "", "b,c", "say \"hi\"", ""},
+		{"1", "2.5", "<nil>"},
+		{"", ""},
+	} {
+		res, err := ReadCSVRow(r)
+		if err != nil {
+			return fmt.Errorf("ReadCSVRow: %w", err)
+		}
+		if err := expect(&res, want...); err != nil {
+			return fmt.Errorf("CSV round trip: %w", err)
+		}
+	}
+	if res, err := ReadCSVRow(r); err != io.EOF || !res.Empty() {
+		return fmt.Errorf("ReadCSVRow: after the last record got %v, %v; want io.EOF", res.ToSlice(), err)
+	}
+	return nil
+}
+
+// Check a WriteTo/ReadFrom round trip through a buffer, with the byte counts reported
+// ReadFrom must stop at the end of the stream, leaving data written after it
+func CheckStream() error {
+	qq := spread(500)
+	qq.Push("end")
+	var buf bytes.Buffer
+	n, err := qq.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		return fmt.Errorf("WriteTo: reported %d bytes, %v; buffer holds %d", n, err, buf.Len())
+	}
+	buf.WriteString("trailer")
+	res := New("replaced")
+	m, err := res.ReadFrom(&buf)
+	if err != nil || m != n {
+		return fmt.Errorf("ReadFrom: read %d bytes, %v; want %d", m, err, n)
+	}
+	if rest := buf.String(); rest != "trailer" {
+		return fmt.Errorf("ReadFrom: left %q after the stream, want trailer", rest)
+	}
+	if !reflect.DeepEqual(res.Each(), qq.Each()) {
+		return fmt.Errorf("Stream round trip: got %v", res.Each())
+	}
+	return nil
+}
+
+// Check GoString gives the expected source for known queues, for values and pointers
+func CheckGoString() error {
+	qq := New(1, "x", nil, 2.5, []int{3})
+	want := `squeue.FromSlice([]interface {}{1, "x", interface {}(nil), 2.5, []int{3}})`
+	if got := qq.GoString(); got != want {
+		return fmt.Errorf("GoString: got %s, want %s", got, want)
+	}
+	var v, p fmt.GoStringer = qq, &qq
+	if v.GoString() != want || p.GoString() != want {
+		return fmt.Errorf("GoString: got %s and %s through fmt.GoStringer", v.GoString(), p.GoString())
+	}
+	empty := New()
+	if got := empty.GoString(); got != "squeue.FromSlice([]interface {}{})" {
+		return fmt.Errorf("GoString: empty queue gave %s", got)
+	}
+	return nil
+}
+
+// Check Diff of equal queues, queues differing in the middle, and queues of different lengths
+func CheckDiff() error {
+	a, b := spread(200), spread(200)
+	if d := Diff(&a, &b); len(d) != 0 {
+		return fmt.Errorf("Diff: equal queues gave %v", d)
+	}
+	b.Swap(50, 150)
+	want := []DiffEntry{{50, 50, 150, true, true}, {150, 150, 50, true, true}}
+	if d := Diff(&a, &b); !reflect.DeepEqual(d, want) {
+		return fmt.Errorf("Diff: differing middle gave %v, want %v", d, want)
+	}
+	b.Swap(50, 150)
+	b.Push(200)
+	b.Push(nil)
+	want = []DiffEntry{{Index: 200, B: 200, InB: true}, {Index: 201, B: nil, InB: true}}
+	if d := Diff(&a, &b); !reflect.DeepEqual(d, want) {
+		return fmt.Errorf("Diff: longer b gave %v, want %v", d, want)
+	}
+	if d := Diff(&b, &a); len(d) != 2 || d[0].String() != "[200]: 200 only in a" {
+		return fmt.Errorf("Diff: longer a gave %v", d)
+	}
+	empty := New()
+	if d := Diff(&empty, &empty); len(d) != 0 {
+		return fmt.Errorf("Diff: empty queues gave %v", d)
+	}
+	return nil
+}
+
+// Check RotateToFront promotes a middle element, leaves a front element in place, and reports misses
+// Moving an element fires no hooks and counts no add or delete
+func CheckRotateToFront() error {
+	qq := spread(200)
+	is := func(n int) func(interface{}) bool {
+		return func(v interface{}) bool { return v == n }
+	}
+	var fired []interface{}
+	qq.OnAdd(func(v interface{}) { fired = append(fired, v) })
+	qq.OnRemove(func(v interface{}) { fired = append(fired, v) })
+	before := qq.Metrics()
+	if !qq.RotateToFront(is(120)) {
+		return fmt.Errorf("RotateToFront: 120 not found")
+	}
+	want := append(append([]interface{}{120}, span(0, 120)...), span(121, 200)...)
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("RotateToFront(120): %w", err)
+	}
+	if len(fired) != 0 || qq.Metrics() != before {
+		return fmt.Errorf("RotateToFront: hooks fired with %v, metrics %+v from %+v", fired, qq.Metrics(), before)
+	}
+	if !qq.RotateToFront(is(120)) {
+		return fmt.Errorf("RotateToFront: front element not found")
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("RotateToFront of the front: %w", err)
+	}
+	if qq.RotateToFront(is(500)) {
+		return fmt.Errorf("RotateToFront: found an absent element")
+	}
+	return expect(&qq, want...)
+}
+
+// Check IsSorted on sorted, unsorted, single-element and empty queues
+// Pairs spanning inner slice boundaries are compared too
+func CheckIsSorted() error {
+	qq := spread(500)
+	if !qq.IsSorted(cmpInt) {
+		return fmt.Errorf("IsSorted: sorted queue reported unsorted")
+	}
+	dup := New(1, 1, 2, 2, 2, 3)
+	if !dup.IsSorted(cmpInt) {
+		return fmt.Errorf("IsSorted: equal neighbours reported unsorted")
+	}
+	for _, i := range []int{1, 249, 250, 499} {
+		qq.Swap(i-1, i)
+		if qq.IsSorted(cmpInt) {
+			return fmt.Errorf("IsSorted: swapping %d and %d not detected", i-1, i)
+		}
+		qq.Swap(i-1, i)
+	}
+	one, empty := New(7), New()
+	if !one.IsSorted(cmpInt) || !empty.IsSorted(cmpInt) {
+		return fmt.Errorf("IsSorted: single-element or empty queue reported unsorted")
+	}
+	return nil
+}
+
+// Check MergeSorted of interleaved queues, with an empty queue, and stability on equal keys
+func CheckMergeSorted() error {
+	evens, odds := New(), New()
+	for i := 0; i < 300; i += 2 {
+		evens.Push(i)
+		odds.Push(i + 1)
+	}
+	res := MergeSorted(&evens, &odds, cmpInt)
+	if err := expect(&res, span(0, 300)...); err != nil {
+		return fmt.Errorf("MergeSorted: %w", err)
+	}
+	empty := New()
+	if res = MergeSorted(&empty, &odds, cmpInt); !res.Equal(&odds) {
+		return fmt.Errorf("MergeSorted: with empty a got %v", res.ToSlice())
+	}
+	if res = MergeSorted(&evens, &empty, cmpInt); !res.Equal(&evens) {
+		return fmt.Errorf("MergeSorted: with empty b got %v", res.ToSlice())
+	}
+	type keyed struct{ key, from int }
+	byKey := func(x, y interface{}) int { return cmpInt(x.(keyed).key, y.(keyed).key) }
+	a := New(keyed{1, 0}, keyed{2, 0}, keyed{2, 1}, keyed{3, 0})
+	b := New(keyed{2, 10}, keyed{3, 10}, keyed{3, 11})
+	res = MergeSorted(&a, &b, byKey)
+	want := []interface{}{keyed{1, 0}, keyed{2, 0}, keyed{2, 1}, keyed{2, 10}, keyed{3, 0}, keyed{3, 10}, keyed{3, 11}}
+	if err := expect(&res, want...); err != nil {
+		return fmt.Errorf("MergeSorted: not stable: %w", err)
+	}
+	if evens.Size() != 150 || odds.Size() != 150 {
+		return fmt.Errorf("MergeSorted: inputs changed to sizes %d and %d", evens.Size(), odds.Size())
+	}
+	return nil
+}
+
+// Check Compact reduces Stats().Slices of a fragmented queue, keeping the elements
+// Within a maximum inner length, the slices are all full but the last
+func CheckCompact() error {
+	qq := spread(5000)
+	for i := 0; i < 15; i++ {
+		qq.Unshift()
+	}
+	before := qq.Stats().Slices
+	qq.Compact()
+	if got := qq.Stats(); got.Slices != 1 || before < 5 {
+		return fmt.Errorf("Compact: %d slices before, %d after; want 1 after", before, got.Slices)
+	}
+	if err := expect(&qq, span(15, 5000)...); err != nil {
+		return fmt.Errorf("Compact: %w", err)
+	}
+	qq = NewWithOptions(WithInitialCapacity(2), WithMaxInnerCap(100))
+	for i := 0; i < 1000; i++ {
+		qq.Push(i)
+		qq.Shift(-i - 1)
+	}
+	for i := 0; i < 550; i++ {
+		qq.Unshift()
+		qq.Pop()
+	}
+	before = qq.Stats().Slices
+	qq.Compact()
+	if got := qq.Stats(); got.Slices != 9 || got.Slices >= before || got.Capacity != 900 {
+		return fmt.Errorf("Compact: %d slices before; after, got %+v; want 9 full slices", before, got)
+	}
+	if err := expect(&qq, span(-450, 450)...); err != nil {
+		return fmt.Errorf("Compact: %w", err)
+	}
+	return nil
+}
+
+// Check a growth factor of 1.5 sizes each new inner slice and the cache by that factor
+// The queue must still hold every element in order across the grows
+func CheckGrowthFactor() error {
+	qq := NewWithOptions(WithInitialCapacity(10), WithGrowthFactor(1.5))
+	lens, slots := []int{10}, []int{len(qq.cache)}
+	for i := 0; i < 20000; i++ {
+		qq.Push(i)
+		if qq.tail != nil && len(qq.tail) != lens[len(lens)-1] {
+			lens = append(lens, len(qq.tail))
+		}
+		if len(qq.cache) != slots[len(slots)-1] {
+			slots = append(slots, len(qq.cache))
+		}
+	}
+	for k := 1; k < len(lens); k++ {
+		if lens[k] != lens[k-1]*3/2 {
+			return fmt.Errorf("WithGrowthFactor: inner slice of length %d followed %d", lens[k], lens[k-1])
+		}
+	}
+	for k := 1; k < len(slots); k++ {
+		if slots[k] != slots[k-1]*3/2 {
+			return fmt.Errorf("WithGrowthFactor: cache of %d slots followed %d", slots[k], slots[k-1])
+		}
+	}
+	if len(lens) < 10 || len(slots) < 3 {
+		return fmt.Errorf("WithGrowthFactor: only %d slice and %d cache sizes seen", len(lens), len(slots))
+	}
+	if err := expect(&qq, span(0, 20000)...); err != nil {
+		return fmt.Errorf("WithGrowthFactor: %w", err)
+	}
+	return nil
+}
+
+// Check Trim, TrimFront and TrimBack strip padding from the ends only
+// An all-matching queue ends up empty
+func CheckTrim() error {
+	pad := func(v interface{}) bool { return v == nil }
+	padded := func() Squeue {
+		qq := New(nil, nil, nil)
+		qq.PushAll(span(0, 200)...)
+		qq.Push(nil)
+		qq.InsertAt(103, nil)
+		qq.PushAll(nil, nil)
+		return qq
+	}
+	inner := append(append(span(0, 100), nil), span(100, 200)...)
+	qq := padded()
+	qq.Trim(pad)
+	if err := expect(&qq, inner...); err != nil {
+		return fmt.Errorf("Trim: %w", err)
+	}
+	qq = padded()
+	qq.TrimFront(pad)
+	if err := expect(&qq, append(inner, nil, nil, nil)...); err != nil {
+		return fmt.Errorf("TrimFront: %w", err)
+	}
+	qq = padded()
+	qq.TrimBack(pad)
+	if err := expect(&qq, append([]interface{}{nil, nil, nil}, inner...)...); err != nil {
+		return fmt.Errorf("TrimBack: %w", err)
+	}
+	qq.Trim(func(interface{}) bool { return true })
+	if !qq.Empty() {
+		return fmt.Errorf("Trim: all matching left %v", qq.ToSlice())
+	}
+	return nil
+}
+
+// Check Replace and ReplaceFunc across inner slice boundaries, counting the matches
+// Size and the other elements are unchanged
+func CheckReplace() error {
+	qq := spread(500)
+	for _, i := range []int{0, 124, 125, 250, 375, 499} {
+		if i%2 == 0 {
+			qq.Replace(i, "x")
+		} else {
+			qq.ReplaceFunc(func(v interface{}) bool { return v == i }, "x")
+		}
+	}
+	want := span(0, 500)
+	for _, i := range []int{0, 124, 125, 250, 375, 499} {
+		want[i] = "x"
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("Replace: %w", err)
+	}
+	if n := qq.Replace("x", "y"); n != 6 {
+		return fmt.Errorf("Replace: replaced %d, want 6", n)
+	}
+	if n := qq.Replace("x", "y"); n != 0 {
+		return fmt.Errorf("Replace: replaced %d absent elements", n)
+	}
+	odd := func(v interface{}) bool { n, ok := v.(int); return ok && n%2 == 1 }
+	if n := qq.ReplaceFunc(odd, 0); n != 247 || qq.Size() != 500 {
+		return fmt.Errorf("ReplaceFunc: replaced %d, want 247; size %d", n, qq.Size())
+	}
+	return nil
+}
+
+// Check RemoveFunc removing scattered matches, and every element
+// The rest keep their order, and only they remain referenced by the inner slices
+func CheckRemoveFunc() error {
+	qq := spread(500)
+	if n := qq.RemoveFunc(func(v interface{}) bool { return v.(int)%7 == 3 }); n != 71 {
+		return fmt.Errorf("RemoveFunc: removed %d, want 71", n)
+	}
+	var want []interface{}
+	for i := 0; i < 500; i++ {
+		if i%7 != 3 {
+			want = append(want, i)
+		}
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("RemoveFunc: %w", err)
+	}
+	held := 0
+	for k, c := 0, qq.cacheF; k < qq.liveSlots(); k, c = k+1, (c+1)%len(qq.cache) {
+		for _, v := range *qq.cache[c].ptr {
+			if v != nil {
+				held++
+			}
+		}
+	}
+	if held != qq.Size() {
+		return fmt.Errorf("RemoveFunc: %d slots still referenced, want %d", held, qq.Size())
+	}
+	if n := qq.RemoveFunc(func(interface{}) bool { return true }); n != len(want) || !qq.Empty() {
+		return fmt.Errorf("RemoveFunc: all matching removed %d, left %v", n, qq.ToSlice())
+	}
+	return nil
+}
+
+// Check WithAutoCompact reclaims capacity as a large queue drains, and that it is off by default
+// After every removal, capacity stays within the ratio of the size (sizes below 10 counting as 10)
+func CheckAutoCompact() error {
+	qq, plain := NewWithOptions(WithAutoCompact(4)), New()
+	qq.PushAll(span(0, 100000)...)
+	plain.PushAll(span(0, 100000)...)
+	peak := qq.Cap()
+	for i := 0; i < 99990; i++ {
+		if i%2 == 0 {
+			qq.Unshift()
+		} else {
+			qq.Pop()
+		}
+		plain.Unshift()
+		if c := qq.Cap(); c > 4*max(qq.Size(), 10) {
+			return fmt.Errorf("WithAutoCompact: capacity %d at size %d", c, qq.Size())
+		}
+	}
+	if qq.Cap() >= peak/100 || plain.Cap() < peak/2 {
+		return fmt.Errorf("WithAutoCompact: capacity %d from %d, %d without the option", qq.Cap(), peak, plain.Cap())
+	}
+	if err := expect(&qq, span(49995, 50005)...); err != nil {
+		return fmt.Errorf("WithAutoCompact: %w", err)
+	}
+	return nil
+}
+
+// Check Enqueue, Dequeue and PeekHead act on the back and front, in FIFO order
+func CheckFIFO() error {
+	qq := New()
+	for i := 0; i < 300; i++ {
+		qq.Enqueue(i)
+		if v, _ := qq.PeekBack(); v != i {
+			return fmt.Errorf("Enqueue: back is %v, want %d", v, i)
+		}
+	}
+	for i := 0; i < 300; i++ {
+		if v, err := qq.PeekHead(); v != i || err != nil {
+			return fmt.Errorf("PeekHead: got %v, %v; want %d", v, err, i)
+		}
+		if v, err := qq.Dequeue(); v != i || err != nil {
+			return fmt.Errorf("Dequeue: got %v, %v; want %d", v, err, i)
+		}
+	}
+	if _, err := qq.Dequeue(); !errors.Is(err, ErrEmpty) {
+		return fmt.Errorf("Dequeue: empty queue gave %v, want ErrEmpty", err)
+	}
+	if _, err := qq.PeekHead(); !errors.Is(err, ErrEmpty) {
+		return fmt.Errorf("PeekHead: empty queue gave %v, want ErrEmpty", err)
+	}
+	return nil
+}
+
+// Check Collect(q.All()) equals q, for a queue spanning several slices and an empty one
+// The collected queue is unbounded, even when q is a ring
+func CheckCollect() error {
+	qq := spread(500)
+	if res := Collect(qq.All()); !res.Equal(&qq) {
+		return fmt.Errorf("Collect: got %v", res.ToSlice())
+	}
+	empty := New()
+	if res := Collect(empty.All()); !res.Empty() {
+		return fmt.Errorf("Collect: empty queue gave %v", res.ToSlice())
+	}
+	ring := NewRing(3)
+	ring.PushAll(1, 2, 3, 4)
+	res := Collect(ring.All())
+	res.Push(5)
+	if err := expect(&res, 2, 3, 4, 5); err != nil {
+		return fmt.Errorf("Collect: %w", err)
+	}
+	return nil
+}
+
+// Check the lock-free Size of a SyncSqueue under mixed concurrent Push, Unshift, Clear and PopWait
+// Meant to be run under go test -race. Readers must never see a size below 0
+// or above the number of elements added so far, and once every goroutine is
+// done the counter must match the wrapped queue
+func CheckSyncSize() error {
+	n := scale
+	s := NewSync()
+	var added atomic.Int64
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		for ctx.Err() == nil {
+			if k := int64(s.Size()); k < 0 || k > added.Load() {
+				done <- fmt.Errorf("SyncSqueue: size %d read with %d added", k, added.Load())
+				return
+			}
+			runtime.Gosched()
+		}
+		done <- nil
+	}()
+	workers := make(chan struct{}, 4)
+	for p := 0; p < 2; p++ {
+		go func(p int) {
+			for i := 0; i < n; i++ {
+				added.Add(1)
+				if p == 0 {
+					s.Push(i)
+				} else {
+					s.Shift(i)
+				}
+			}
+			workers <- struct{}{}
+		}(p)
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			s.Unshift()
+		}
+		workers <- struct{}{}
+	}()
+	go func() {
+		for i := 0; i < n/100; i++ {
+			s.Clear()
+			runtime.Gosched()
+		}
+		workers <- struct{}{}
+	}()
+	// Consumers wait until the others are done, then are cancelled
+	consumers := make(chan struct{}, 2)
+	for c := 0; c < 2; c++ {
+		go func() {
+			for ctx.Err() == nil {
+				s.PopWait(ctx)
+			}
+			consumers <- struct{}{}
+		}()
+	}
+	for w := 0; w < 4; w++ {
+		<-workers
+	}
+	cancel()
+	<-consumers
+	<-consumers
+	if err := <-done; err != nil {
+		return err
+	}
+	if got, want := s.Size(), s.sq.Size(); got != want || s.Empty() != (want == 0) {
+		return fmt.Errorf("SyncSqueue: counter holds %d, queue holds %d", got, want)
+	}
+	s.Clear()
+	if !s.Empty() || s.Size() != 0 {
+		return fmt.Errorf("SyncSqueue: %d elements left after Clear", s.Size())
+	}
+	return nil
+}
+
+// Check RemoveFirst and RemoveLast target the first and last of duplicate elements
+func CheckRemoveFirstLast() error {
+	qq := spread(300)
+	qq.Replace(10, "dup")
+	qq.Replace(150, "dup")
+	qq.Replace(290, "dup")
+	if !qq.RemoveFirst("dup") {
+		return fmt.Errorf("RemoveFirst: dup not found")
+	}
+	if i, j := qq.IndexOf("dup"), qq.LastIndexOf("dup"); i != 149 || j != 289 {
+		return fmt.Errorf("RemoveFirst: dups left at %d and %d, want 149 and 289", i, j)
+	}
+	if !qq.RemoveLast("dup") {
+		return fmt.Errorf("RemoveLast: dup not found")
+	}
+	if i, j := qq.IndexOf("dup"), qq.LastIndexOf("dup"); i != 149 || j != 149 || qq.Size() != 298 {
+		return fmt.Errorf("RemoveLast: dups left at %d and %d, size %d", i, j, qq.Size())
+	}
+	if qq.RemoveFirst("absent") || qq.RemoveLast("absent") || qq.Size() != 298 {
+		return fmt.Errorf("RemoveFirst/RemoveLast: removed an absent element")
+	}
+	want := append(append(span(0, 10), span(11, 150)...), "dup")
+	want = append(append(want, span(151, 290)...), span(291, 300)...)
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("RemoveFirst/RemoveLast: %w", err)
+	}
+	return nil
+}
+
+// Check NewSized allocates no inner slice until the queue holds more than its capacity
+// Adds at either end fill the one head slice; the next add allocates another
+func CheckNewSized() error {
+	qq := NewSized(300, 0, 1)
+	head := &qq.head[0]
+	for i := 2; i < 300; i++ {
+		if i%3 == 0 {
+			qq.Shift(-i)
+		} else {
+			qq.Push(i)
+		}
+		if st := qq.Stats(); st.Slices != 1 || st.Capacity != 300 || &qq.head[0] != head || qq.Metrics().Grows != 0 {
+			return fmt.Errorf("NewSized: %+v at size %d, want one slice of 300", st, qq.Size())
+		}
+	}
+	qq.Push(300)
+	if st := qq.Stats(); st.Slices != 2 || st.Capacity <= 300 {
+		return fmt.Errorf("NewSized: %+v past capacity, want a second slice", st)
+	}
+	if small := NewSized(2, "a", "b", "c"); small.Cap() != 3 || small.Size() != 3 {
+		return fmt.Errorf("NewSized: capacity %d for 3 initial values, want 3", small.Cap())
+	}
+	if empty := NewSized(0); empty.Cap() != 1 || !empty.Empty() {
+		return fmt.Errorf("NewSized(0): capacity %d, want 1", empty.Cap())
+	}
+	return mustPanic("squeue: invalid capacity", func() { NewSized(-1) })
+}
+
+// Check Window after a ring overflows holds the newest elements in order, and is not consumed
+func CheckWindow() error {
+	ring := NewRing(100)
+	for i := 0; i < 1000; i++ {
+		ring.Push(i)
+		if w := ring.Window(); !reflect.DeepEqual(w, span(max(0, i-99), i+1)) {
+			return fmt.Errorf("Window: after pushing %d got %v", i, w)
+		}
+	}
+	w := ring.Window()
+	w[0] = "changed"
+	if again := ring.Window(); !reflect.DeepEqual(again, span(900, 1000)) || ring.Size() != 100 {
+		return fmt.Errorf("Window: repeated read gave %v", again)
+	}
+	return nil
+}
+
+// Check EvictOlderThan removes the time-ordered prefix before the cutoff, keeping times paired
+func CheckTimed() error {
+	ts, start := NewTimed(), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Second) }
+	for i := 0; i < 500; i++ {
+		ts.PushTimed(i, at(i/2))
+	}
+	if n := ts.EvictOlderThan(start); n != 0 || ts.Size() != 500 {
+		return fmt.Errorf("EvictOlderThan: removed %d before the first time", n)
+	}
+	if n := ts.EvictOlderThan(at(100)); n != 200 || ts.Size() != 300 {
+		return fmt.Errorf("EvictOlderThan: removed %d, want 200", n)
+	}
+	if elem, t, err := ts.PeekFront(); elem != 200 || !t.Equal(at(100)) || err != nil {
+		return fmt.Errorf("EvictOlderThan: front is %v at %v, %v", elem, t, err)
+	}
+	if got := ts.Each(); !reflect.DeepEqual(got, span(200, 500)) {
+		return fmt.Errorf("EvictOlderThan: left %v", got)
+	}
+	if elem, t, _ := ts.Unshift(); elem != 200 || !t.Equal(at(100)) {
+		return fmt.Errorf("Unshift: got %v at %v", elem, t)
+	}
+	if n := ts.EvictOlderThan(at(1000)); n != 299 || !ts.Empty() {
+		return fmt.Errorf("EvictOlderThan: removed %d of the rest, want 299", n)
+	}
+	if _, _, err := ts.Unshift(); !errors.Is(err, ErrEmpty) {
+		return fmt.Errorf("Unshift: empty queue gave %v, want ErrEmpty", err)
+	}
+	return nil
+}
+
+// Check Squeue and SqueueOf behave identically over the same random sequence of operations
+// After each operation the results, sizes, peeks, Each and String must agree
+func CheckOf() error {
+	rng := rand.New(rand.NewSource(1))
+	qq, gq := New(), NewOf[int]()
+	for k := 0; k < 20000; k++ {
+		var v, gv interface{}
+		var err, gerr error
+		// Mostly adds for the first half, then mostly deletes
+		op := rng.Intn(6)
+		if k >= 10000 {
+			op += 2
+		}
+		switch {
+		case op < 2:
+			qq.Push(k)
+			gq.Push(k)
+		case op < 4:
+			qq.Shift(k)
+			gq.Shift(k)
+		case op < 6:
+			v, err = qq.Unshift()
+			gv, gerr = gq.Unshift()
+		default:
+			v, err = qq.Pop()
+			gv, gerr = gq.Pop()
+		}
+		if err != nil {
+			v = 0
+		}
+		if v != gv || (err == nil) != (gerr == nil) {
+			return fmt.Errorf("SqueueOf: operation %d gave %v, %v; Squeue gave %v, %v", k, gv, gerr, v, err)
+		}
+		if qq.Size() != gq.Size() || qq.Empty() != gq.Empty() {
+			return fmt.Errorf("SqueueOf: size %d after operation %d, Squeue %d", gq.Size(), k, qq.Size())
+		}
+		for _, peek := range []struct {
+			name string
+			f    func() (interface{}, error)
+			g    func() (int, error)
+		}{{"PeekFront", qq.PeekFront, gq.PeekFront}, {"PeekBack", qq.PeekBack, gq.PeekBack}} {
+			v, err := peek.f()
+			gv, gerr := peek.g()
+			if err != nil {
+				v = 0
+			}
+			if v != gv || (err == nil) != (gerr == nil) {
+				return fmt.Errorf("SqueueOf: %s after operation %d gave %v, %v; Squeue gave %v, %v", peek.name, k, gv, gerr, v, err)
+			}
+		}
+		if k%100 == 0 && (qq.String() != gq.String() || fmt.Sprint(qq.Each()) != fmt.Sprint(gq.Each())) {
+			return fmt.Errorf("SqueueOf: after operation %d holds %v; Squeue %v", k, gq.String(), qq.String())
+		}
+	}
+	return nil
+}
+
+// Check nil elements are stored like any other across several inner slices
+// Size, Each, the peeks and removals at both ends must all round-trip them
+func CheckNil() error {
+	qq := New()
+	var want []interface{}
+	for i := 0; i < 300; i++ {
+		var v interface{}
+		if i%3 != 0 {
+			v = i
+		}
+		if i%2 == 0 {
+			qq.Push(v)
+			want = append(want, v)
+		} else {
+			qq.Shift(v)
+			want = append([]interface{}{v}, want...)
+		}
+	}
+	qq.Push(nil)
+	qq.Shift(nil)
+	want = append(append([]interface{}{nil}, want...), nil)
+	if qq.Stats().Slices < 3 {
+		return fmt.Errorf("nil elements: %d slices, want 3 or more", qq.Stats().Slices)
+	}
+	if got := qq.Each(); qq.Size() != len(want) || !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("nil elements: got %v (size %d), want %v", got, qq.Size(), want)
+	}
+	if v, err := qq.PeekFront(); v != nil || err != nil {
+		return fmt.Errorf("nil elements: PeekFront gave %v, %v; want nil, nil", v, err)
+	}
+	if v, err := qq.PeekBack(); v != nil || err != nil {
+		return fmt.Errorf("nil elements: PeekBack gave %v, %v; want nil, nil", v, err)
+	}
+	for i, j := 0, len(want)-1; i <= j; i, j = i+1, j-1 {
+		if v, err := qq.Unshift(); v != want[i] || err != nil {
+			return fmt.Errorf("nil elements: Unshift %d gave %v, %v; want %v", i, v, err, want[i])
+		}
+		if i == j {
+			break
+		}
+		if v, err := qq.Pop(); v != want[j] || err != nil {
+			return fmt.Errorf("nil elements: Pop %d gave %v, %v; want %v", j, v, err, want[j])
+		}
+	}
+	if !qq.Empty() {
+		return fmt.Errorf("nil elements: %d left", qq.Size())
+	}
+	return nil
+}
+
+// Check At and PeekAt match ToSlice at every index of a queue spanning head, cached and tail slices
+// Both leave the queue untouched, and index -1 and Size() give ErrRange
+func CheckAt() error {
+	qq := spread(1000)
+	qq.UnshiftN(7)
+	qq.PopN(5)
+	s, before := qq.ToSlice(), frozen(&qq)
+	if qq.liveSlots() < 4 {
+		return fmt.Errorf("At: %d slices, want head, tail and cached slices", qq.liveSlots())
+	}
+	for i, want := range s {
+		if v, err := qq.At(i); v != want || err != nil {
+			return fmt.Errorf("At(%d): got %v, %v; want %v", i, v, err, want)
+		}
+		if v, err := qq.PeekAt(i); v != want || err != nil {
+			return fmt.Errorf("PeekAt(%d): got %v, %v; want %v", i, v, err, want)
+		}
+	}
+	for _, i := range []int{-1, qq.Size()} {
+		if _, err := qq.At(i); !errors.Is(err, ErrRange) {
+			return fmt.Errorf("At(%d): got %v, want ErrRange", i, err)
+		}
+		if _, err := qq.PeekAt(i); !errors.Is(err, ErrRange) {
+			return fmt.Errorf("PeekAt(%d): got %v, want ErrRange", i, err)
+		}
+	}
+	empty := New()
+	if _, err := empty.At(0); !errors.Is(err, ErrRange) {
+		return fmt.Errorf("At(0): empty queue gave %v, want ErrRange", err)
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check All and All2 over a queue spanning several slices, with early breaks and an empty queue
+// Breaking out must not panic (yield called again after it returned false) or move any pointers
+func CheckIterators() error {
+	qq := spread(500)
+	before := frozen(&qq)
+	var got []interface{}
+	for v := range qq.All() {
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, span(0, 500)) {
+		return fmt.Errorf("All: got %v", got)
+	}
+	next := 0
+	for i, v := range qq.All2() {
+		if i != next || v != i {
+			return fmt.Errorf("All2: pair %d gave %d, %v", next, i, v)
+		}
+		next++
+	}
+	if next != 500 {
+		return fmt.Errorf("All2: %d pairs, want 500", next)
+	}
+	for _, stop := range []int{0, 1, 137, 499} {
+		n := 0
+		for v := range qq.All() {
+			if n++; v == stop {
+				break
+			}
+		}
+		if n != stop+1 {
+			return fmt.Errorf("All: break at %d after %d elements", stop, n)
+		}
+		n = 0
+		for i := range qq.All2() {
+			if n++; i == stop {
+				break
+			}
+		}
+		if n != stop+1 {
+			return fmt.Errorf("All2: break at %d after %d pairs", stop, n)
+		}
+	}
+	empty := New()
+	for v := range empty.All() {
+		return fmt.Errorf("All: empty queue yielded %v", v)
+	}
+	for i, v := range empty.All2() {
+		return fmt.Errorf("All2: empty queue yielded %d, %v", i, v)
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check Backward yields the reverse of Each, stops on break, and yields nothing for an empty queue
+// The cache is wrapped, so the reverse walk crosses its end
+func CheckBackward() error {
+	qq := NewWithOptions(WithInitialCapacity(2), WithMaxInnerCap(8))
+	for i := 0; i < 100; i++ {
+		qq.Push(i)
+		qq.Shift(-i - 1)
+	}
+	before, each := frozen(&qq), qq.Each()
+	var got []interface{}
+	for v := range qq.Backward() {
+		got = append(got, v)
+	}
+	for i, j := 0, len(each)-1; i < j; i, j = i+1, j-1 {
+		each[i], each[j] = each[j], each[i]
+	}
+	if !reflect.DeepEqual(got, each) {
+		return fmt.Errorf("Backward: got %v, want %v", got, each)
+	}
+	n := 0
+	for v := range qq.Backward() {
+		if n++; v == 0 {
+			break
+		}
+	}
+	if n != 100 {
+		return fmt.Errorf("Backward: break at 0 after %d elements, want 100", n)
+	}
+	empty := New()
+	for v := range empty.Backward() {
+		return fmt.Errorf("Backward: empty queue yielded %v", v)
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check a Clone and its original are independent: changes to either leave the other as it was
+func CheckClone() error {
+	qq := spread(500)
+	cl := qq.Clone()
+	cl.Push("clone")
+	cl.Unshift()
+	cl.Swap(1, 2)
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("Clone: original: %w", err)
+	}
+	qq.Shift("original")
+	qq.PopN(100)
+	qq.Replace(250, nil)
+	want := append(append([]interface{}{1, 3, 2}, span(4, 500)...), "clone")
+	if err := expect(&cl, want...); err != nil {
+		return fmt.Errorf("Clone: clone: %w", err)
+	}
+	empty := New()
+	if cl = empty.Clone(); !cl.Empty() {
+		return fmt.Errorf("Clone: empty queue gave %v", cl.ToSlice())
+	}
+	cl.Push(1)
+	if !empty.Empty() {
+		return fmt.Errorf("Clone: push to a clone of an empty queue reached the original")
+	}
+	return nil
+}
+
+// Check ToSlice returns a fresh slice: writing to it leaves the queue as it was, and vice versa
+func CheckToSlice() error {
+	qq := spread(500)
+	s := qq.ToSlice()
+	for i := range s {
+		s[i] = "changed"
+	}
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("ToSlice: %w", err)
+	}
+	s = qq.ToSlice()
+	qq.Replace(0, "changed")
+	qq.Unshift()
+	if s[0] != 0 || len(s) != 500 {
+		return fmt.Errorf("ToSlice: slice changed with the queue to %v", s[:2])
+	}
+	empty := New()
+	if s = empty.ToSlice(); s == nil || len(s) != 0 {
+		return fmt.Errorf("ToSlice: empty queue gave %#v, want an empty slice", s)
+	}
+	return nil
+}
+
+// Check FromSlice of lengths 0, 1 and one that spans several slices once added to
+// The source slice is not retained
+func CheckFromSlice() error {
+	for _, n := range []int{0, 1, 5000} {
+		s := span(0, n)
+		qq := FromSlice(s)
+		if err := expect(&qq, span(0, n)...); err != nil {
+			return fmt.Errorf("FromSlice(%d): %w", n, err)
+		}
+		for i := range s {
+			s[i] = "changed"
+		}
+		qq.PushAll(span(n, n+5000)...)
+		qq.Shift(-1)
+		if err := expect(&qq, append([]interface{}{-1}, span(0, n+5000)...)...); err != nil {
+			return fmt.Errorf("FromSlice(%d): after adds: %w", n, err)
+		}
+	}
+	if qq := FromSlice(nil); !qq.Empty() {
+		return fmt.Errorf("FromSlice(nil): got %v", qq.ToSlice())
+	}
+	return nil
+}
+
+// Check a gob round trip keeps the elements in order, including nils, and empties the receiver for an empty queue
+func CheckGob() error {
+	qq := spread(500)
+	qq.Push(nil)
+	qq.Shift("start")
+	qq.Replace(250, nil)
+	data, err := qq.GobEncode()
+	if err != nil {
+		return fmt.Errorf("GobEncode: %w", err)
+	}
+	res := New("replaced")
+	if err := res.GobDecode(data); err != nil {
+		return fmt.Errorf("GobDecode: %w", err)
+	}
+	if !reflect.DeepEqual(res.Each(), qq.Each()) {
+		return fmt.Errorf("Gob round trip: got %v", res.Each())
+	}
+	empty := New()
+	if data, err = empty.GobEncode(); err == nil {
+		err = res.GobDecode(data)
+	}
+	if err != nil || !res.Empty() {
+		return fmt.Errorf("Gob round trip: empty queue gave %v, %v", res.Each(), err)
+	}
+	return nil
+}
+
+// Check Cap stays at least Size, and grows by doubling slices as a queue is pushed past capacity
+// Each new inner slice is twice the length of the one before; the empty queue has a 20-slot head
+func CheckCap() error {
+	qq := New()
+	if qq.Cap() != 20 {
+		return fmt.Errorf("Cap: new queue holds %d, want 20", qq.Cap())
+	}
+	last, step := qq.Cap(), qq.Cap()
+	for i := 0; i < 5000; i++ {
+		qq.Push(i)
+		c := qq.Cap()
+		if c < qq.Size() {
+			return fmt.Errorf("Cap: %d below size %d", c, qq.Size())
+		}
+		if c != last {
+			if c-last != 2*step || qq.Size() != last+1 {
+				return fmt.Errorf("Cap: grew from %d to %d at size %d, want a slice of %d once full", last, c, qq.Size(), 2*step)
+			}
+			last, step = c, c-last
+		}
+	}
+	if qq.Stats().Slices < 5 {
+		return fmt.Errorf("Cap: %d slices, want 5 or more", qq.Stats().Slices)
+	}
+	for !qq.Empty() {
+		qq.Unshift()
+		if qq.Cap() < qq.Size() {
+			return fmt.Errorf("Cap: %d below size %d", qq.Cap(), qq.Size())
+		}
+	}
+	return nil
+}
+
+// Check PushAll and ShiftAll keep the argument order across head and tail boundaries
+// ShiftAll(a, b, c) puts a at the front, not c; no arguments add nothing.
+// PushAll allocates the room it needs at once
+func CheckPushShiftAll() error {
+	qq := New()
+	for k := 0; k < 10; k++ {
+		qq.PushAll(span(100*k, 100*k+100)...)
+		qq.ShiftAll(span(-100*k-100, -100*k)...)
+	}
+	qq.PushAll()
+	qq.ShiftAll()
+	if qq.Stats().Slices < 3 {
+		return fmt.Errorf("PushAll/ShiftAll: %d slices, want 3 or more", qq.Stats().Slices)
+	}
+	if err := expect(&qq, span(-1000, 1000)...); err != nil {
+		return fmt.Errorf("PushAll/ShiftAll: %w", err)
+	}
+	empty := New()
+	empty.ShiftAll("a", "b", "c")
+	if err := expect(&empty, "a", "b", "c"); err != nil {
+		return fmt.Errorf("ShiftAll: %w", err)
+	}
+	// A large PushAll fills the head, then one slice allocated up front;
+	// a ring allocates no more than its bound however many are pushed
+	big := New()
+	big.PushAll(span(0, 5000)...)
+	if s := big.Stats(); s.Slices != 2 || s.Capacity != 5000 {
+		return fmt.Errorf("PushAll: 5000 elements in %+v, want 2 slices holding 5000", s)
+	}
+	ring := NewRing(100)
+	ring.PushAll(span(0, 5000)...)
+	if ring.Cap() > 200 {
+		return fmt.Errorf("PushAll: ring of 100 grew to %d", ring.Cap())
+	}
+	return expect(&ring, span(4900, 5000)...)
+}
+
+// Check Reverse of a multi-slice queue, and that the queue works normally afterwards
+func CheckReverse() error {
+	qq := spread(500)
+	qq.Reverse()
+	want := span(0, 500)
+	for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+		want[i], want[j] = want[j], want[i]
+	}
+	if err := expect(&qq, want...); err != nil {
+		return fmt.Errorf("Reverse: %w", err)
+	}
+	qq.Push(-1)
+	qq.Shift(500)
+	if v, _ := qq.Pop(); v != -1 {
+		return fmt.Errorf("Reverse: pop gave %v, want -1", v)
+	}
+	if v, _ := qq.Unshift(); v != 500 {
+		return fmt.Errorf("Reverse: unshift gave %v, want 500", v)
+	}
+	qq.Reverse()
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("Reverse twice: %w", err)
+	}
+	empty, one := New(), New(1)
+	empty.Reverse()
+	one.Reverse()
+	if !empty.Empty() || one.Size() != 1 {
+		return fmt.Errorf("Reverse: empty and single-element queues changed")
+	}
+	return nil
+}
+
+// Check Contains and ContainsFunc for present, absent and empty-queue cases
+// ContainsFunc stops at the first match
+func CheckContains() error {
+	qq := spread(500)
+	before := frozen(&qq)
+	for _, v := range []int{0, 249, 250, 499} {
+		if !qq.Contains(v) {
+			return fmt.Errorf("Contains(%d): false", v)
+		}
+	}
+	if qq.Contains(500) || qq.Contains("0") || qq.Contains(nil) {
+		return fmt.Errorf("Contains: found an absent element")
+	}
+	calls := 0
+	if !qq.ContainsFunc(func(v interface{}) bool { calls++; return v.(int) >= 300 }) || calls != 301 {
+		return fmt.Errorf("ContainsFunc: %d calls for a match at index 300, want 301", calls)
+	}
+	if qq.ContainsFunc(func(v interface{}) bool { return v.(int) < 0 }) {
+		return fmt.Errorf("ContainsFunc: matched no element")
+	}
+	empty := New()
+	if empty.Contains(nil) || empty.ContainsFunc(func(interface{}) bool { return true }) {
+		return fmt.Errorf("Contains: empty queue reported a match")
+	}
+	return samePointers(&qq, &before)
+}
+
+// Check IndexOf and LastIndexOf pick the first and last of duplicates across slices, and -1 if absent
+func CheckIndexOf() error {
+	qq := spread(500)
+	for _, i := range []int{3, 240, 260, 497} {
+		qq.Replace(i, "dup")
+	}
+	if i := qq.IndexOf("dup"); i != 3 {
+		return fmt.Errorf("IndexOf: got %d, want 3", i)
+	}
+	if i := qq.LastIndexOf("dup"); i != 497 {
+		return fmt.Errorf("LastIndexOf: got %d, want 497", i)
+	}
+	if i, j := qq.IndexOf(250), qq.LastIndexOf(250); i != 250 || j != 250 {
+		return fmt.Errorf("IndexOf/LastIndexOf(250): got %d, %d", i, j)
+	}
+	if i, j := qq.IndexOf(500), qq.LastIndexOf(500); i != -1 || j != -1 {
+		return fmt.Errorf("IndexOf/LastIndexOf: absent element at %d, %d", i, j)
+	}
+	empty := New()
+	if i, j := empty.IndexOf(nil), empty.LastIndexOf(nil); i != -1 || j != -1 {
+		return fmt.Errorf("IndexOf/LastIndexOf: empty queue gave %d, %d", i, j)
+	}
+	return nil
+}
+
+// Check RemoveAt at the front, in the middle across slices, and at the back, and out of range
+func CheckRemoveAt() error {
+	qq := spread(500)
+	want := span(0, 500)
+	for _, i := range []int{0, 498, 123, 250, 251, 300, 1} {
+		v, err := qq.RemoveAt(i)
+		if err != nil || v != want[i] {
+			return fmt.Errorf("RemoveAt(%d): got %v, %v; want %v", i, v, err, want[i])
+		}
+		want = append(want[:i:i], want[i+1:]...)
+		if err := expect(&qq, want...); err != nil {
+			return fmt.Errorf("RemoveAt(%d): %w", i, err)
+		}
+	}
+	for _, i := range []int{-1, qq.Size()} {
+		if _, err := qq.RemoveAt(i); !errors.Is(err, ErrRange) {
+			return fmt.Errorf("RemoveAt(%d): got %v, want ErrRange", i, err)
+		}
+	}
+	empty := New()
+	if _, err := empty.RemoveAt(0); !errors.Is(err, ErrRange) {
+		return fmt.Errorf("RemoveAt(0): empty queue gave %v, want ErrRange", err)
+	}
+	return expect(&qq, want...)
+}
+
+// Check InsertAt at both ends, in the middle across slices, out of range,
+// and on full bounded queues and rings
+func CheckInsertAt() error {
+	qq := spread(500)
+	want := span(0, 500)
+	for k, i := range []int{0, 501, 250, 124, 376, 1, 503} {
+		if err := qq.InsertAt(i, "new"); err != nil {
+			return fmt.Errorf("InsertAt(%d): %w", i, err)
+		}
+		want = append(want[:i:i], append([]interface{}{"new"}, want[i:]...)...)
+		if err := expect(&qq, want...); err != nil {
+			return fmt.Errorf("InsertAt %d at %d: %w", k, i, err)
+		}
+	}
+	for _, i := range []int{-1, qq.Size() + 1} {
+		if err := qq.InsertAt(i, "bad"); !errors.Is(err, ErrRange) {
+			return fmt.Errorf("InsertAt(%d): got %v, want ErrRange", i, err)
+		}
+	}
+	empty := New()
+	if err := empty.InsertAt(0, "only"); err != nil {
+		return fmt.Errorf("InsertAt(0): empty queue gave %v", err)
+	}
+	if err := expect(&empty, "only"); err != nil {
+		return fmt.Errorf("InsertAt(0): %w", err)
+	}
+	// A full bounded queue or ring refuses the element, evicting nothing;
+	// with room, both insert as an unbounded queue does
+	bounded, ring := NewBounded(30, span(0, 29)...), NewRing(30)
+	ring.PushAll(span(0, 29)...)
+	for name, full := range map[string]*Squeue{"bounded": &bounded, "ring": &ring} {
+		if err := full.InsertAt(10, "new"); err != nil {
+			return fmt.Errorf("InsertAt: %s with room gave %v", name, err)
+		}
+		for _, i := range []int{0, 10, 30} {
+			if err := full.InsertAt(i, "over"); !errors.Is(err, ErrFull) {
+				return fmt.Errorf("InsertAt(%d): full %s gave %v, want ErrFull", i, name, err)
+			}
+		}
+		if err := expect(full, append(span(0, 10), append([]interface{}{"new"}, span(10, 29)...)...)...); err != nil {
+			return fmt.Errorf("InsertAt: full %s: %w", name, err)
+		}
+	}
+	return expect(&qq, want...)
+}
+
+// Check Filter keeping all, none, and some elements, leaving the receiver untouched
+func CheckFilter() error {
+	qq := spread(500)
+	all := qq.Filter(func(interface{}) bool { return true })
+	if err := expect(&all, span(0, 500)...); err != nil {
+		return fmt.Errorf("Filter: keeping all: %w", err)
+	}
+	if none := qq.Filter(func(interface{}) bool { return false }); !none.Empty() {
+		return fmt.Errorf("Filter: keeping none gave %v", none.ToSlice())
+	}
+	odd := qq.Filter(func(v interface{}) bool { return v.(int)%2 == 1 })
+	var want []interface{}
+	for i := 1; i < 500; i += 2 {
+		want = append(want, i)
+	}
+	if err := expect(&odd, want...); err != nil {
+		return fmt.Errorf("Filter: keeping odd: %w", err)
+	}
+	empty := New()
+	if res := empty.Filter(func(interface{}) bool { return true }); !res.Empty() {
+		return fmt.Errorf("Filter: empty queue gave %v", res.ToSlice())
+	}
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("Filter: receiver: %w", err)
+	}
+	return nil
+}
+
+// Check Map transforms each element in order, stores nil results, and leaves the receiver untouched
+func CheckMap() error {
+	qq := spread(500)
+	res := qq.Map(func(v interface{}) interface{} {
+		if v.(int)%5 == 0 {
+			return nil
+		}
+		return v.(int) * 2
+	})
+	want := make([]interface{}, 500)
+	for i := range want {
+		if i%5 != 0 {
+			want[i] = i * 2
+		}
+	}
+	if err := expect(&res, want...); err != nil {
+		return fmt.Errorf("Map: %w", err)
+	}
+	empty := New()
+	if res = empty.Map(func(v interface{}) interface{} { return v }); !res.Empty() {
+		return fmt.Errorf("Map: empty queue gave %v", res.ToSlice())
+	}
+	if err := expect(&qq, span(0, 500)...); err != nil {
+		return fmt.Errorf("Map: receiver: %w", err)
+	}
+	return nil
+}
+
+// Check Equal and EqualFunc on equal queues built differently, and on unequal ones
+func CheckEqual() error {
+	a, b, c := New(), New(), spread(500)
+	a.PushAll(span(0, 500)...)
+	for i := 499; i >= 0; i-- {
+		b.Shift(i)
+	}
+	if !a.Equal(&b) || !b.Equal(&c) || !c.Equal(&a) {
+		return fmt.Errorf("Equal: queues built by push, shift and both differ")
+	}
+	b.Replace(250, "x")
+	if a.Equal(&b) || b.Equal(&a) {
+		return fmt.Errorf("Equal: queues differing at 250 reported equal")
+	}
+	c.Pop()
+	if a.Equal(&c) || c.Equal(&a) {
+		return fmt.Errorf("Equal: queues of different sizes reported equal")
+	}
+	x, y := New([]int{1}, []int{2}), New([]int{1}, []int{2})
+	eq := func(p, q interface{}) bool { return reflect.DeepEqual(p, q) }
+	if !x.EqualFunc(&y, eq) || x.EqualFunc(&a, eq) {
+		return fmt.Errorf("EqualFunc: wrong result for slices")
+	}
+	e1, e2 := New(), New()
+	if !e1.Equal(&e2) || e1.Equal(&a) {
+		return fmt.Errorf("Equal: wrong result for empty queues")
+	}
+	return nil
+}
+
+// Check Drain calls fn in the order of Each, and leaves the queue empty and usable
+func CheckDrain() error {
+	qq := spread(500)
+	want := qq.Each()
+	var got []interface{}
+	qq.Drain(func(v interface{}) { got = append(got, v) })
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("Drain: got %v", got)
+	}
+	if !qq.Empty() {
+		return fmt.Errorf("Drain: %d left", qq.Size())
+	}
+	qq.Push(1)
+	qq.Shift(0)
+	if err := expect(&qq, 0, 1); err != nil {
+		return fmt.Errorf("Drain: reuse: %w", err)
+	}
+	empty := New()
+	empty.Drain(func(v interface{}) { got = append(got, v) })
+	if len(got) != 500 {
+		return fmt.Errorf("Drain: empty queue called fn")
+	}
+	return nil
+}
+
+// Check UnshiftN and PopN for n = 0, n within and beyond the size, an empty queue, and n < 0
+func CheckRemoveN() error {
+	qq := spread(500)
+	if s, err := qq.UnshiftN(0); len(s) != 0 || err != nil || qq.Size() != 500 {
+		return fmt.Errorf("UnshiftN(0): got %v, %v", s, err)
+	}
+	if s, err := qq.PopN(0); len(s) != 0 || err != nil || qq.Size() != 500 {
+		return fmt.Errorf("PopN(0): got %v, %v", s, err)
+	}
+	if s, err := qq.UnshiftN(200); err != nil || !reflect.DeepEqual(s, span(0, 200)) {
+		return fmt.Errorf("UnshiftN(200): got %v, %v", s, err)
+	}
+	s, err := qq.PopN(200)
+	if err != nil || len(s) != 200 || s[0] != 499 || s[199] != 300 {
+		return fmt.Errorf("PopN(200): got %v, %v", s, err)
+	}
+	if s, err = qq.PopN(150); err != nil || len(s) != 100 || s[0] != 299 || !qq.Empty() {
+		return fmt.Errorf("PopN(150) of 100: got %v, %v", s, err)
+	}
+	if s, err = qq.UnshiftN(5); err != nil || len(s) != 0 {
+		return fmt.Errorf("UnshiftN(5): empty queue gave %v, %v", s, err)
+	}
+	qq = spread(10)
+	if s, err = qq.UnshiftN(20); err != nil || !reflect.DeepEqual(s, span(0, 10)) || !qq.Empty() {
+		return fmt.Errorf("UnshiftN(20) of 10: got %v, %v", s, err)
+	}
+	if _, err = qq.UnshiftN(-1); err == nil {
+		return fmt.Errorf("UnshiftN(-1): no error")
+	}
+	if _, err = qq.PopN(-1); err == nil {
+		return fmt.Errorf("PopN(-1): no error")
+	}
+	return nil
+}
+
+// Check Grow(n) leaves Cap at least Size+n, and the n pushes that follow allocate nothing
+// Covers an empty queue, a queue spread across the cache, and n beyond the maximum inner length
+func CheckGrow() error {
+	for _, size := range []int{0, 1000} {
+		for _, n := range []int{0, 5, 1000, 250000} {
+			qq := spread(size)
+			qq.Grow(n)
+			c := qq.Cap()
+			if c < size+n {
+				return fmt.Errorf("Grow(%d) on %d elements: Cap %d, want at least %d", n, size, c, size+n)
+			}
+			if s := qq.Stats(); s.Capacity != c {
+				return fmt.Errorf("Grow(%d) on %d elements: Stats capacity %d, Cap %d", n, size, s.Capacity, c)
+			}
+			qq.PushAll(span(size, size+n)...)
+			if qq.Cap() != c {
+				return fmt.Errorf("Grow(%d) on %d elements: Cap went from %d to %d over the pushes", n, size, c, qq.Cap())
+			}
+			if err := expect(&qq, span(0, size+n)...); err != nil {
+				return fmt.Errorf("Grow(%d) on %d elements: %w", n, size, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check a pooled queue allocates inner slices of power of two lengths, whatever length is asked for
+// Slices pass through the pool as the queue drains and refills, keeping the elements intact
+func CheckPooledLengths() error {
+	qq := NewPooled()
+	for _, n := range []int{30, 77, 1000, 1234} {
+		qq.Grow(n)
+		qq.PushAll(span(0, n)...)
+		// Slot 0 keeps the initial head, allocated by New at the default length
+		for c, k := qq.cacheF, 0; k < qq.liveSlots(); c, k = (c+1)%len(qq.cache), k+1 {
+			if l := len(*qq.cache[c].ptr); c != 0 && l&(l-1) != 0 {
+				return fmt.Errorf("NewPooled: Grow(%d) left an inner slice of length %d", n, l)
+			}
+		}
+		if err := expect(&qq, span(0, n)...); err != nil {
+			return fmt.Errorf("NewPooled: Grow(%d): %w", n, err)
+		}
+		for !qq.Empty() {
+			qq.Pop()
+		}
+	}
+	return nil
+}
+
 var mem runtime.MemStats
 var scale int = 10000
 var mod int = 7
@@ -416,3 +3520,314 @@ func runtimeStats() (int64, uint64) {
 	runtime.ReadMemStats(&mem)
 	return time.Now().UnixNano(), mem.TotalAlloc
 }
+
+func churnSQTest(newQueue func() Squeue) (int64, uint64) {
+	startT, startM := runtimeStats()
+
+	n := int(math.Sqrt(float64(scale)))
+	for r := 0; r < 10; r++ {
+		qq := newQueue()
+		for i := 0; i < n; i++ {
+			for j := 0; j < n-i; j++ {
+				qq.Push(i)
+			}
+			for j := 0; j <= i; j++ {
+				qq.Unshift()
+			}
+		}
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+// Grows qq by a different count each round, then pushes that many elements and pops them all
+func growPopSQTest(qq Squeue) (int64, uint64) {
+	startT, startM := runtimeStats()
+
+	n := int(math.Sqrt(float64(scale)))
+	for i := 0; i < n; i++ {
+		qq.Grow(n + i)
+		for j := 0; j < n+i; j++ {
+			qq.Push(j)
+		}
+		for j := 0; j < n+i; j++ {
+			qq.Pop()
+		}
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+func readSQTest(read func(qq *Squeue, buf []interface{}) []interface{}) (int64, uint64) {
+	qq := New()
+	n := int(math.Sqrt(float64(scale)))
+	for i := 0; i < n; i++ {
+		qq.Push(i)
+	}
+	var buf []interface{}
+
+	startT, startM := runtimeStats()
+
+	for r := 0; r < n; r++ {
+		buf = read(&qq, buf)
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+func pushPopIntSQTest() (int64, uint64) {
+	startT, startM := runtimeStats()
+
+	qq := NewInt()
+	for i := 0; i < scale; i++ {
+		qq.Push(i)
+		qq.Unshift()
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+func linearIntSQTest() (int64, uint64) {
+	startT, startM := runtimeStats()
+
+	qq := NewInt()
+	for i := 0; i < scale; i++ {
+		qq.Push(i)
+	}
+	for i := 0; i < scale; i++ {
+		qq.Unshift()
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+func compareEnds(qq *Squeue, ll *list.List) error {
+	if qq.Size() != ll.Len() {
+		return fmt.Errorf("size %d, want %d", qq.Size(), ll.Len())
+	}
+	if ll.Len() == 0 {
+		if _, err := qq.PeekFront(); err == nil {
+			return fmt.Errorf("PeekFront on empty queue succeeded")
+		}
+		if _, err := qq.PeekBack(); err == nil {
+			return fmt.Errorf("PeekBack on empty queue succeeded")
+		}
+		return nil
+	}
+	if front, _ := qq.PeekFront(); front != ll.Front().Value {
+		return fmt.Errorf("front %v, want %v", front, ll.Front().Value)
+	}
+	if back, _ := qq.PeekBack(); back != ll.Back().Value {
+		return fmt.Errorf("back %v, want %v", back, ll.Back().Value)
+	}
+	return nil
+}
+
+func burstSQTest(grow bool) (int64, uint64) {
+	startT, startM := runtimeStats()
+
+	n := int(math.Sqrt(float64(scale)))
+	for r := 0; r < n; r++ {
+		qq := New()
+		if grow {
+			qq.Grow(n)
+		}
+		for i := 0; i < n; i++ {
+			qq.Push(i)
+		}
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+func peekSQTest(qq *Squeue) (int64, uint64) {
+	startT, startM := runtimeStats()
+
+	for i := 0; i < scale; i++ {
+		qq.Front()
+		qq.Back()
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+func refillSQTest(reuse bool) (int64, uint64) {
+	n := int(math.Sqrt(float64(scale)))
+	qq := New()
+	fill := func() {
+		if !reuse {
+			qq = New()
+		}
+		for i := 0; i < n; i++ {
+			qq.Push(nil)
+		}
+		qq.Clear()
+	}
+	// Warm up until Clear retains a slice large enough for a whole fill
+	for r := 0; r < 3; r++ {
+		fill()
+	}
+
+	startT, startM := runtimeStats()
+
+	for r := 0; r < n; r++ {
+		fill()
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+// Returns a queue holding 0, ..., n-1, half shifted and half pushed so it spans several inner slices
+func spread(n int) Squeue {
+	return spreadOf(span(0, n))
+}
+
+// Returns a queue holding the elements of s, half shifted and half pushed as for spread
+func spreadOf(s []interface{}) Squeue {
+	qq := New()
+	for i := len(s)/2 - 1; i >= 0; i-- {
+		qq.Shift(s[i])
+	}
+	for _, elem := range s[len(s)/2:] {
+		qq.Push(elem)
+	}
+	return qq
+}
+
+// Returns the ints i, ..., j-1 as elements
+func span(i, j int) []interface{} {
+	s := []interface{}{}
+	for ; i < j; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+// Returns an error unless qq holds exactly want, front to back
+func expect(qq *Squeue, want ...interface{}) error {
+	got := qq.ToSlice()
+	if qq.Size() != len(want) || len(got) != len(want) || len(want) > 0 && !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("got %v (size %d), want %v", got, qq.Size(), want)
+	}
+	return nil
+}
+
+// Returns an error unless fn panics with want
+func mustPanic(want interface{}, fn func()) (err error) {
+	defer func() {
+		switch r := recover(); {
+		case r == nil:
+			err = fmt.Errorf("did not panic, want %v", want)
+		case r != want:
+			err = fmt.Errorf("panicked with %v, want %v", r, want)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// Returns a copy of qq's pointers and cache, to compare against with samePointers
+func frozen(qq *Squeue) Squeue {
+	before := *qq
+	before.cache = append([]*Cached(nil), qq.cache...)
+	return before
+}
+
+// Returns an error unless qq and before have the same slices and pointers
+func samePointers(qq, before *Squeue) error {
+	if qq.headF != before.headF || qq.headL != before.headL || qq.headN != before.headN ||
+		qq.tailF != before.tailF || qq.tailL != before.tailL || qq.tailN != before.tailN ||
+		qq.cacheF != before.cacheF || qq.cacheL != before.cacheL || qq.cacheSize != before.cacheSize ||
+		&qq.head[0] != &before.head[0] || len(qq.cache) != len(before.cache) {
+		return fmt.Errorf("pointers moved:\n%s\nwant:\n%s", qq.DebugString(), before.DebugString())
+	}
+	for c := range qq.cache {
+		if qq.cache[c] != before.cache[c] {
+			return fmt.Errorf("cache slot %d changed", c)
+		}
+	}
+	return nil
+}
+
+func tryRemoveSQTest(fill bool) (int64, uint64) {
+	qq := New()
+
+	startT, startM := runtimeStats()
+
+	for i := 0; i < scale; i++ {
+		if fill {
+			qq.Push(nil)
+		}
+		qq.TryUnshift()
+		if fill {
+			qq.Push(nil)
+		}
+		qq.TryPop()
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+// Runs pushes, shifts and removes on qq, keeping its size steady after a
+// warm-up, so the adds and removes themselves are timed rather than allocation
+func hotPathSQTest(qq Squeue) (int64, uint64) {
+	for i := 0; i < 1000; i++ {
+		qq.Push(nil)
+	}
+
+	startT, startM := runtimeStats()
+
+	for i := 0; i < scale; i++ {
+		qq.Push(nil)
+		qq.Shift(nil)
+		qq.Unshift()
+		qq.Pop()
+	}
+
+	endT, endM := runtimeStats()
+
+	elapsed, used := endT-startT, uint64(endM-startM)
+
+	return elapsed, used
+}
+
+// Compares int elements, as cmp functions for Search and the sorted methods do
+func cmpInt(a, b interface{}) int {
+	return a.(int) - b.(int)
+}